@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 定期校验的检查间隔
+const auditCheckInterval = time.Hour
+
+// AuditProblem 校验发现的问题
+type AuditProblem struct {
+	Save   *SaveGame `json:"save"`
+	Kind   string    `json:"kind"` // 缺失、损坏、错误
+	Detail string    `json:"detail"`
+}
+
+// AuditReport 云端存档校验报告
+type AuditReport struct {
+	Started  time.Time       `json:"started"`
+	Finished time.Time       `json:"finished"`
+	Full     bool            `json:"full"` // 是否下载校验完整内容
+	Checked  int             `json:"checked"`
+	Problems []*AuditProblem `json:"problems"`
+}
+
+// Summary 报告摘要
+func (r *AuditReport) Summary() string {
+	mode := "快速校验"
+	if r.Full {
+		mode = "完整校验"
+	}
+	return fmt.Sprintf("%s于 %s 完成，检查 %d 个存档，发现 %d 个问题",
+		mode, r.Finished.Format("2006-01-02 15:04:05"), r.Checked, len(r.Problems))
+}
+
+// 校验报告保存路径
+func getAuditReportPath() string {
+	return filepath.Join(getAppDataDir(), "audit_report.json")
+}
+
+func loadAuditReport() *AuditReport {
+	data, err := os.ReadFile(getAuditReportPath())
+	if err != nil {
+		return nil
+	}
+
+	var report AuditReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil
+	}
+	return &report
+}
+
+func saveAuditReport(report *AuditReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(getAuditReportPath(), data, 0644)
+}
+
+// runAudit 校验所有云端存档；full 为 true 时下载并校验哈希和压缩包，否则只检查文件是否存在及大小
+func (c *Client) runAudit(ctx context.Context, full bool, progress func(done, total int)) (*AuditReport, error) {
+	if !atomic.CompareAndSwapInt32(&c.auditRunning, 0, 1) {
+		return nil, fmt.Errorf("校验正在进行中")
+	}
+	defer atomic.StoreInt32(&c.auditRunning, 0)
+
+	report := &AuditReport{Started: time.Now(), Full: full}
+	log.Printf("🔍 开始校验云端存档 (完整: %v)\n", full)
+
+	saves, err := c.api.Load().ListAllSaves(ctx, ListSavesOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取云端存档失败: %w", err)
+	}
+
+	addProblem := func(save *SaveGame, kind, detail string) {
+		log.Printf("❌ 存档%s: %s (%s) - %s\n", kind, save.FileName, save.ID, detail)
+		report.Problems = append(report.Problems, &AuditProblem{Save: save, Kind: kind, Detail: detail})
+	}
+
+	for i, save := range saves {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if progress != nil {
+			progress(i, len(saves))
+		}
+		report.Checked++
+
+		exists, size, err := c.api.Load().HeadSave(ctx, save.ID)
+		if err != nil {
+			addProblem(save, "错误", err.Error())
+			continue
+		}
+		if !exists {
+			addProblem(save, "缺失", "服务端找不到存档文件")
+			continue
+		}
+		if size >= 0 && save.FileSize > 0 && size != save.FileSize {
+			addProblem(save, "损坏", fmt.Sprintf("文件大小不匹配: 期望 %d 字节，实际 %d 字节", save.FileSize, size))
+			continue
+		}
+
+		if !full {
+			continue
+		}
+
+		data, err := c.api.Load().DownloadSave(ctx, save.ID, nil)
+		if err != nil {
+			addProblem(save, "错误", err.Error())
+			continue
+		}
+		if err := verifyArchive(save, data); err != nil {
+			addProblem(save, "损坏", err.Error())
+		}
+	}
+
+	if progress != nil {
+		progress(len(saves), len(saves))
+	}
+
+	report.Finished = time.Now()
+	log.Printf("🔍 %s\n", report.Summary())
+
+	if err := saveAuditReport(report); err != nil {
+		log.Printf("保存校验报告失败: %v\n", err)
+	}
+
+	return report, nil
+}
+
+// monitorAudit 按配置的间隔定期校验云端存档
+func (c *Client) monitorAudit() {
+	ticker := time.NewTicker(auditCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		hours := c.config.Load().AuditIntervalHours
+		if hours <= 0 {
+			continue
+		}
+
+		if last := loadAuditReport(); last != nil && time.Since(last.Finished) < time.Duration(hours)*time.Hour {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Hour)
+		report, err := c.runAudit(ctx, c.config.Load().AuditFullDownload, nil)
+		cancel()
+		if err != nil {
+			log.Printf("定期校验失败: %v\n", err)
+			continue
+		}
+
+		if len(report.Problems) > 0 {
+			c.app.SendNotification(&fyne.Notification{
+				Title:   "BG3 存档同步",
+				Content: fmt.Sprintf("云端存档校验发现 %d 个问题，请在\"校验\"中查看", len(report.Problems)),
+			})
+		}
+	}
+}
+
+// showAudit 云端存档校验窗口
+func (c *Client) showAudit() {
+	win := c.app.NewWindow("校验云端存档")
+	win.Resize(fyne.NewSize(700, 450))
+
+	report := loadAuditReport()
+
+	summary := widget.NewLabel("尚未校验过云端存档")
+	if report != nil {
+		summary.SetText(report.Summary())
+	}
+
+	progress := widget.NewProgressBar()
+	progress.Hide()
+
+	problems := widget.NewList(
+		func() int {
+			if report == nil {
+				return 0
+			}
+			return len(report.Problems)
+		},
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			p := report.Problems[id]
+			item.(*widget.Label).SetText(fmt.Sprintf("[%s] %s - %s: %s",
+				p.Kind,
+				p.Save.Timestamp.Format("2006-01-02 15:04:05"),
+				p.Save.CampaignName(),
+				p.Detail,
+			))
+		},
+	)
+
+	var quickBtn, fullBtn *widget.Button
+	start := func(full bool) {
+		quickBtn.Disable()
+		fullBtn.Disable()
+		progress.SetValue(0)
+		progress.Show()
+		summary.SetText("正在校验...")
+
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Hour)
+			defer cancel()
+
+			result, err := c.runAudit(ctx, full, func(done, total int) {
+				fyne.Do(func() {
+					if total > 0 {
+						progress.SetValue(float64(done) / float64(total))
+					}
+					summary.SetText(fmt.Sprintf("正在校验... %d / %d", done, total))
+				})
+			})
+
+			fyne.Do(func() {
+				quickBtn.Enable()
+				fullBtn.Enable()
+				progress.Hide()
+
+				if err != nil {
+					summary.SetText(fmt.Sprintf("校验失败: %v", err))
+					dialog.ShowError(err, win)
+					return
+				}
+
+				report = result
+				summary.SetText(report.Summary())
+				problems.Refresh()
+			})
+		}()
+	}
+
+	quickBtn = widget.NewButton("快速校验", func() { start(false) })
+	fullBtn = widget.NewButton("完整校验 (下载全部)", func() { start(true) })
+
+	win.SetContent(container.NewPadded(container.NewBorder(
+		container.NewVBox(
+			container.NewHBox(quickBtn, fullBtn),
+			progress,
+			summary,
+		),
+		nil, nil, nil,
+		problems,
+	)))
+	win.Show()
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 本地备份文件名中的时间格式
+const localBackupTimeFormat = "20060102-150405"
+
+// LocalBackup 恢复前自动保留的本地存档备份
+type LocalBackup struct {
+	Path     string
+	Campaign string // 存档文件夹名
+	Time     time.Time
+	Size     int64
+}
+
+// 本地备份目录
+func getBackupsDir() string {
+	return filepath.Join(getAppDataDir(), "backups")
+}
+
+// backupLocalFolder 在覆盖本地存档前将其打包备份，返回备份文件路径；文件夹不存在时跳过并返回空字符串
+func backupLocalFolder(folderPath string) (string, error) {
+	if _, err := os.Stat(folderPath); os.IsNotExist(err) {
+		return "", nil
+	}
+
+	zipData, err := zipFolder(folderPath)
+	if err != nil {
+		return "", fmt.Errorf("打包本地存档失败: %w", err)
+	}
+
+	dir := filepath.Join(getBackupsDir(), filepath.Base(folderPath))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("创建备份目录失败: %w", err)
+	}
+
+	backupPath := filepath.Join(dir, time.Now().Format(localBackupTimeFormat)+".zip")
+	if err := os.WriteFile(backupPath, zipData, 0644); err != nil {
+		return "", fmt.Errorf("写入备份失败: %w", err)
+	}
+
+	log.Printf("已备份本地存档: %s\n", backupPath)
+	return backupPath, nil
+}
+
+// listLocalBackups 列出所有本地备份，按时间倒序
+func listLocalBackups() ([]*LocalBackup, error) {
+	campaigns, err := os.ReadDir(getBackupsDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []*LocalBackup
+	for _, campaign := range campaigns {
+		if !campaign.IsDir() {
+			continue
+		}
+
+		dir := filepath.Join(getBackupsDir(), campaign.Name())
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || filepath.Ext(name) != ".zip" {
+				continue
+			}
+
+			t, err := time.ParseInLocation(localBackupTimeFormat, strings.TrimSuffix(name, ".zip"), time.Local)
+			if err != nil {
+				continue
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+
+			backups = append(backups, &LocalBackup{
+				Path:     filepath.Join(dir, name),
+				Campaign: campaign.Name(),
+				Time:     t,
+				Size:     info.Size(),
+			})
+		}
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+
+	return backups, nil
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Campaign 同一存档文件夹（战役）下的所有云端版本
+type Campaign struct {
+	Folder        string
+	GameMode      string
+	Profile       string      // 所属玩家档案
+	CharacterName string      // 最近一个读取到角色名的版本中的角色
+	Saves         []*SaveGame // 按时间倒序
+}
+
+// Latest 最新版本
+func (cp *Campaign) Latest() *SaveGame {
+	return cp.Saves[0]
+}
+
+// Title 战役显示名称
+func (cp *Campaign) Title() string {
+	if cp.GameMode == profileGameMode {
+		return "玩家档案"
+	}
+
+	// 优先显示角色名，读取不到时使用最新版本的存档名，旧版本上传的存档没有存档名时使用文件夹名
+	name := cp.CharacterName
+	if name == "" {
+		name = cp.Latest().SaveName
+	}
+	if name == "" {
+		name = cp.Folder
+	}
+	if cp.GameMode != "" {
+		name = fmt.Sprintf("%s [%s]", name, cp.GameMode)
+	}
+	if cp.Profile != "" && cp.Profile != defaultPlayerProfile {
+		name = fmt.Sprintf("%s (%s)", name, cp.Profile)
+	}
+	return name
+}
+
+// Key 战役的唯一标识：不同玩家档案中的同名文件夹是不同的战役
+func (cp *Campaign) Key() string {
+	return campaignKey(cp.Profile, cp.Folder)
+}
+
+func campaignKey(profile, folder string) string {
+	return profile + "/" + folder
+}
+
+// groupByCampaign 将存档按玩家档案和战役文件夹分组，战役按最近一次存档时间倒序
+func groupByCampaign(saves []*SaveGame) []*Campaign {
+	index := make(map[string]*Campaign)
+	var campaigns []*Campaign
+
+	for _, save := range saves {
+		// 旧版本上传的存档没有记录玩家档案，当时只支持默认玩家档案
+		folder, profile := save.CampaignName(), save.Profile
+		if profile == "" {
+			profile = defaultPlayerProfile
+		}
+		key := campaignKey(profile, folder)
+		cp, ok := index[key]
+		if !ok {
+			cp = &Campaign{Folder: folder, GameMode: save.GameMode, Profile: profile}
+			if cp.GameMode == "" {
+				cp.GameMode = gameModeOf(folder)
+			}
+			index[key] = cp
+			campaigns = append(campaigns, cp)
+		}
+		cp.Saves = append(cp.Saves, save)
+	}
+
+	for _, cp := range campaigns {
+		sort.SliceStable(cp.Saves, func(i, j int) bool {
+			return cp.Saves[i].Timestamp.After(cp.Saves[j].Timestamp)
+		})
+		for _, save := range cp.Saves {
+			if save.CharacterName != "" {
+				cp.CharacterName = save.CharacterName
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(campaigns, func(i, j int) bool {
+		return campaigns[i].Latest().Timestamp.After(campaigns[j].Latest().Timestamp)
+	})
+
+	return campaigns
+}
+
+// makeSaveBrowser 创建两级存档浏览器：左侧战役，右侧该战役的版本历史
+func (c *Client) makeSaveBrowser() fyne.CanvasObject {
+	c.campaignList = widget.NewList(
+		func() int { return len(c.campaigns) },
+		func() fyne.CanvasObject {
+			return container.NewVBox(
+				widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabel(""),
+			)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			if id >= len(c.campaigns) {
+				return
+			}
+
+			cp := c.campaigns[id]
+			latest := cp.Latest()
+			box := item.(*fyne.Container)
+
+			box.Objects[0].(*widget.Label).SetText(cp.Title())
+			// 读取不到游戏时长的存档（如 zstd 压缩的存档包）不显示时长
+			details := fmt.Sprintf("设备: %s · %d 个版本", latest.DeviceID, len(cp.Saves))
+			if latest.GameTime > 0 {
+				details = fmt.Sprintf("时长: %s · %s", formatPlaytime(latest.GameTime), details)
+			}
+			box.Objects[1].(*widget.Label).SetText(details)
+		},
+	)
+	c.campaignList.OnSelected = func(id widget.ListItemID) {
+		if id >= len(c.campaigns) {
+			return
+		}
+		c.selectedCampaign = c.campaigns[id].Key()
+		c.revisionList.UnselectAll()
+		c.revisionList.ScrollToTop()
+		c.revisionList.Refresh()
+	}
+
+	c.revisionList = widget.NewList(
+		func() int { return len(c.selectedRevisions()) },
+		func() fyne.CanvasObject {
+			return container.NewBorder(
+				nil, nil,
+				widget.NewIcon(resourceLockSvg),
+				container.NewHBox(
+					widget.NewButton("恢复", nil),
+					widget.NewButton("删除", nil),
+					widget.NewButton("固定", nil),
+					widget.NewButton("备注", nil),
+				),
+				widget.NewLabel(""),
+			)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			revisions := c.selectedRevisions()
+			if id >= len(revisions) {
+				return
+			}
+
+			save := revisions[id]
+			row := item.(*fyne.Container)
+
+			text := fmt.Sprintf("%s - %s (%s)",
+				save.Timestamp.Format("2006-01-02 15:04:05"),
+				save.DeviceID,
+				formatSize(save.FileSize),
+			)
+			if save.Notes != "" {
+				text += " · " + save.Notes
+			}
+			if len(save.Tags) > 0 {
+				text += " " + formatTags(save.Tags)
+			}
+
+			label := row.Objects[0].(*widget.Label)
+			label.Truncation = fyne.TextTruncateEllipsis
+			label.SetText(text)
+
+			// 已固定的存档显示锁图标
+			lockIcon := row.Objects[1].(*widget.Icon)
+			if save.Pinned {
+				lockIcon.Show()
+			} else {
+				lockIcon.Hide()
+			}
+
+			buttons := row.Objects[2].(*fyne.Container)
+
+			restoreBtn := buttons.Objects[0].(*widget.Button)
+			restoreBtn.OnTapped = func() {
+				c.restoreSave(save)
+			}
+
+			deleteBtn := buttons.Objects[1].(*widget.Button)
+			deleteBtn.OnTapped = func() {
+				c.deleteSave(save)
+			}
+			if save.Pinned {
+				deleteBtn.Disable()
+			} else {
+				deleteBtn.Enable()
+			}
+
+			pinBtn := buttons.Objects[2].(*widget.Button)
+			if save.Pinned {
+				pinBtn.SetText("取消固定")
+			} else {
+				pinBtn.SetText("固定")
+			}
+			pinBtn.OnTapped = func() {
+				c.togglePin(save)
+			}
+
+			notesBtn := buttons.Objects[3].(*widget.Button)
+			notesBtn.OnTapped = func() {
+				c.showEditMetadata(save)
+			}
+		},
+	)
+
+	split := container.NewHSplit(c.campaignList, c.revisionList)
+	split.Offset = 0.4
+	return split
+}
+
+// selectedRevisions 当前选中战役的版本历史
+func (c *Client) selectedRevisions() []*SaveGame {
+	for _, cp := range c.campaigns {
+		if cp.Key() == c.selectedCampaign {
+			return cp.Saves
+		}
+	}
+	return nil
+}
+
+// updateSaveBrowser 按已加载的存档和搜索关键字重新分组并刷新浏览器（需在主线程调用）
+func (c *Client) updateSaveBrowser(saves []*SaveGame) {
+	var matched []*SaveGame
+	for _, save := range saves {
+		if matchesSearch(save, c.searchQuery) {
+			matched = append(matched, save)
+		}
+	}
+	c.campaigns = groupByCampaign(matched)
+
+	// 保持原来的选中项，不存在则选中第一个
+	selected := -1
+	for i, cp := range c.campaigns {
+		if cp.Key() == c.selectedCampaign {
+			selected = i
+			break
+		}
+	}
+	if selected < 0 && len(c.campaigns) > 0 {
+		selected = 0
+	}
+
+	c.campaignList.Refresh()
+	if selected >= 0 {
+		c.campaignList.Select(selected)
+	} else {
+		c.selectedCampaign = ""
+		c.campaignList.UnselectAll()
+	}
+	c.revisionList.Refresh()
+}
+
+// togglePin 切换存档的固定状态
+func (c *Client) togglePin(save *SaveGame) {
+	pinned := !save.Pinned
+	c.statusBar.Set("正在更新存档...")
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		updated, err := c.api.Load().UpdateSaveMetadata(ctx, save.ID, &SaveMetadataUpdate{Pinned: &pinned})
+		if err != nil {
+			fyne.Do(func() {
+				c.statusBar.Set(fmt.Sprintf("更新失败: %v", err))
+				dialog.ShowError(err, c.mainWin)
+			})
+			return
+		}
+
+		fyne.Do(func() {
+			save.Pinned = updated.Pinned
+			c.revisionList.Refresh()
+			if save.Pinned {
+				c.statusBar.Set("已固定存档")
+			} else {
+				c.statusBar.Set("已取消固定")
+			}
+		})
+	}()
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// 检查点存档的标签
+const checkpointTag = "checkpoint"
+
+// IsCheckpoint 是否是检查点存档
+func (s *SaveGame) IsCheckpoint() bool {
+	return slices.Contains(s.Tags, checkpointTag)
+}
+
+// latestSaveFolder 在所有存档目录中找到最近修改的战役存档文件夹
+func latestSaveFolder(game *GameDefinition, savePaths, gameModes []string) (string, error) {
+	var latest string
+	var latestTime time.Time
+	for _, savePath := range savePaths {
+		// 某个玩家档案的目录无法读取时跳过，不影响其他玩家档案
+		entries, err := os.ReadDir(savePath)
+		if err != nil {
+			log.Printf("读取存档目录失败 %s: %v\n", savePath, err)
+			continue
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() || !game.MatchesMode(entry.Name(), gameModes) {
+				continue
+			}
+
+			info, err := readSaveInfo(filepath.Join(savePath, entry.Name()))
+			if err != nil {
+				continue
+			}
+			if info.ModTime.After(latestTime) {
+				latest = filepath.Join(savePath, entry.Name())
+				latestTime = info.ModTime
+			}
+		}
+	}
+
+	if latest == "" {
+		return "", fmt.Errorf("没有找到存档文件夹")
+	}
+	return latest, nil
+}
+
+// createCheckpoint 立即上传最近修改的存档（不等待防抖）并标记为检查点
+func (c *Client) createCheckpoint() {
+	// 避免连按快捷键重复上传
+	if !atomic.CompareAndSwapInt32(&c.checkpointRunning, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&c.checkpointRunning, 0)
+
+	folderPath, err := latestSaveFolder(c.game(), c.watchedSavePaths(), c.config.Load().GameModes)
+	if err != nil {
+		log.Printf("创建检查点失败: %v\n", err)
+		c.notifyCheckpoint("创建检查点失败: " + err.Error())
+		return
+	}
+
+	log.Printf("📍 创建检查点: %s\n", folderPath)
+	save := c.handleSaveFolder(folderPath)
+	if save == nil {
+		c.notifyCheckpoint("创建检查点失败，详见状态栏")
+		return
+	}
+
+	name := fmt.Sprintf("检查点 %s", time.Now().Format("01-02 15:04:05"))
+	notes := name
+	if save.Notes != "" {
+		notes = save.Notes + " · " + name
+	}
+	tags := append(append([]string{}, save.Tags...), checkpointTag)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	updated, err := c.api.Load().UpdateSaveMetadata(ctx, save.ID, &SaveMetadataUpdate{
+		Notes: &notes,
+		Tags:  &tags,
+	})
+	if err != nil {
+		log.Printf("标记检查点失败: %v\n", err)
+		// 没有标记成功也不能被当作退出时的自动保存删除
+		c.replaceLastUpload(save, nil)
+		c.notifyCheckpoint("存档已上传，但标记检查点失败: " + err.Error())
+		return
+	}
+
+	c.replaceLastUpload(save, updated)
+	c.statusBar.Set("已创建" + name)
+	c.notifyCheckpoint("已创建" + name)
+}
+
+func (c *Client) notifyCheckpoint(content string) {
+	c.app.SendNotification(&fyne.Notification{
+		Title:   "BG3 存档同步",
+		Content: content,
+	})
+}
+
+// setupCheckpointHotkey 注册创建检查点的快捷键：窗口内快捷键，以及支持时的全局快捷键
+func (c *Client) setupCheckpointHotkey() {
+	if c.stopHotkey != nil {
+		c.stopHotkey()
+		c.stopHotkey = nil
+	}
+	if c.checkpointShortcut != nil {
+		c.mainWin.Canvas().RemoveShortcut(c.checkpointShortcut)
+		c.checkpointShortcut = nil
+	}
+
+	hotkey := c.config.Load().CheckpointHotkey
+	if hotkey == "" {
+		return
+	}
+
+	hk, err := parseHotkey(hotkey)
+	if err != nil {
+		log.Printf("检查点快捷键无效: %v\n", err)
+		return
+	}
+
+	c.checkpointShortcut = hk.shortcut()
+	c.mainWin.Canvas().AddShortcut(c.checkpointShortcut, func(fyne.Shortcut) {
+		go c.createCheckpoint()
+	})
+
+	stop, err := registerGlobalHotkey(hk, c.createCheckpoint)
+	if err != nil {
+		if errors.Is(err, errGlobalHotkeyUnsupported) {
+			log.Printf("%v，检查点快捷键 %s 仅在主窗口中生效\n", err, hk)
+		} else {
+			log.Printf("%v\n", err)
+		}
+		return
+	}
+
+	c.stopHotkey = stop
+	log.Printf("已注册全局检查点快捷键: %s\n", hk)
+}
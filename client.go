@@ -2,13 +2,16 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
-	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -21,47 +24,147 @@ import (
 )
 
 type Client struct {
-	config    *Config
-	api       *NebulaAPI
-	watcher   *fsnotify.Watcher
-	app       fyne.App
-	mainWin   fyne.Window
-	statusBar binding.String
+	config     atomic.Pointer[Config]    // 实际使用的配置（配置文件 + 环境变量和命令行参数），修改设置时整体替换，不修改其中的内容
+	fileConfig *Config                   // 配置文件中的内容，保存设置时只修改这一层
+	options    *Options                  // 命令行参数，重新加载配置文件时仍然生效
+	api        atomic.Pointer[NebulaAPI] // 修改服务器或网络设置后替换
+	watcher    *fsnotify.Watcher
+	watchLock  sync.Mutex // 保护 watcher，修改存档目录设置后重新监听
+	app        fyne.App
+	mainWin    fyne.Window
+	statusBar  binding.String
 
 	// 进程监控
-	gameRunning      bool
-	lastUploadedSave *SaveGame // 最后一次上传的存档
-	lastUploadTime   time.Time // 最后一次上传的时间
+	gameRunning      atomic.Bool // 由进程监控协程写入，上传和限速时读取
+	uploadLock       sync.Mutex  // 保护最后一次上传的记录，上传、检查点和退出时的清理在不同协程中
+	lastUploadedSave *SaveGame   // 最后一次上传的存档
+	lastUploadTime   time.Time   // 最后一次上传的时间
 	lastModTimes     sync.Map
 
 	// 健康检查
 	healthStatus     bool         // 当前健康状态
 	lastHealthStatus bool         // 上次健康状态
 	healthLock       sync.RWMutex // 保护健康状态的锁
+	healthLabel      *widget.Label
+	stopHealth       context.CancelFunc // 停止当前的健康检查
+
+	autoSyncCheck *widget.Check
+
+	// 存档列表（分页加载）
+	savesLock   sync.Mutex
+	saves       []*SaveGame      // 已加载的存档
+	nextCursor  string           // 下一页游标，为空表示没有更多
+	savesGen    int              // 每次重新加载时加一，丢弃旧请求的响应
+	savesBusy   bool             // 正在加载下一页
+	saveFilter  ListSavesOptions // 当前过滤条件
+	loadMoreBtn *widget.Button
+
+	// 战役浏览（仅在主线程访问）
+	campaigns        []*Campaign
+	selectedCampaign string // 当前选中的战役，见 Campaign.Key
+	searchQuery      string // 按备注、标签等搜索已加载的存档
+	campaignList     *widget.List
+	revisionList     *widget.List
+
+	// 保留策略
+	retentionLock    sync.Mutex
+	lastRetentionRun time.Time
+
+	// 检查点快捷键
+	checkpointRunning  int32
+	checkpointShortcut *desktop.CustomShortcut
+	stopHotkey         func() // 注销全局快捷键
+
+	// 云端存档校验
+	auditRunning int32
+
+	// 上传/下载进度
+	transferLock     sync.Mutex
+	transfer         *transfer // 界面上显示的传输，为空表示没有
+	transferBox      *fyne.Container
+	transferLabel    *widget.Label
+	transferProgress *widget.ProgressBar
+	trayMenu         *fyne.Menu
+	trayStatus       *fyne.MenuItem
+
+	// 玩家档案同步
+	profileLock sync.Mutex // 同一时间只打包上传一个玩家档案
+
+	// 镜像上传
+	mirrorLock sync.Mutex
+	mirrorAPIs map[string]*NebulaAPI
+
+	modManifests modManifestCache // 后台计算的 Mods 清单
 }
 
-func NewClient(config *Config, app fyne.App) *Client {
+// 存档列表每页数量
+const savesPageSize = 50
+
+// NewClient 创建客户端，fileConfig 为配置文件中的内容，config 为应用覆盖值后实际使用的配置
+func NewClient(fileConfig, config *Config, opts *Options, app fyne.App) *Client {
 	statusBar := binding.NewString()
 	statusBar.Set("就绪")
 
-	return &Client{
-		config:           config,
-		api:              NewNebulaAPI(config.NebulaURL, config.DeviceID),
+	c := &Client{
+		fileConfig:       fileConfig,
+		options:          opts,
 		app:              app,
 		statusBar:        statusBar,
 		healthStatus:     true, // 初始假设网络正常
 		lastHealthStatus: true,
 	}
+	c.config.Store(config)
+	c.api.Store(c.buildAPI())
+	c.applyBandwidthLimits()
+
+	return c
+}
+
+// buildAPI 按当前配置创建 API 客户端
+func (c *Client) buildAPI() *NebulaAPI {
+	cfg := c.config.Load()
+	api, err := NewNebulaAPI(cfg.NebulaURL, cfg.DeviceID, cfg.Network)
+	if err != nil {
+		// 证书或代理设置有误时不使用这些设置，仍然允许打开设置修改
+		log.Printf("⚠️  网络设置无效，使用默认设置: %v\n", err)
+		c.statusBar.Set(fmt.Sprintf("网络设置无效: %v", err))
+		api, _ = NewNebulaAPI(cfg.NebulaURL, cfg.DeviceID, NetworkConfig{Timeouts: cfg.Network.Timeouts})
+	}
+	return api
+}
+
+// applyBandwidthLimits 按游戏是否运行应用限速
+func (c *Client) applyBandwidthLimits() {
+	bandwidth := c.config.Load().Bandwidth
+	running := c.gameRunning.Load()
+	upload, download := bandwidth.Rates(running)
+	c.api.Load().SetBandwidth(upload, download)
+	log.Printf("限速: %s\n", bandwidth.Describe(running))
 }
 
 func (c *Client) setupSystemTray(desk desktop.App) {
-	menu := fyne.NewMenu("BG3 存档同步",
+	// 托盘菜单第一项显示传输进度
+	c.trayStatus = fyne.NewMenuItem("没有进行中的传输", nil)
+	c.trayStatus.Disabled = true
+
+	c.trayMenu = fyne.NewMenu("BG3 存档同步",
+		c.trayStatus,
+		fyne.NewMenuItem("取消传输", func() {
+			c.cancelTransfer()
+		}),
+		fyne.NewMenuItemSeparator(),
 		fyne.NewMenuItem("打开主界面", func() {
 			c.mainWin.Show()
 		}),
 		fyne.NewMenuItem("立即同步", func() {
 			c.manualSync()
 		}),
+		fyne.NewMenuItem("创建检查点", func() {
+			go c.createCheckpoint()
+		}),
+		fyne.NewMenuItem("为最近上传添加备注", func() {
+			c.editLastUploadNotes()
+		}),
 		fyne.NewMenuItemSeparator(),
 		fyne.NewMenuItem("设置", func() {
 			c.showSettings()
@@ -71,7 +174,7 @@ func (c *Client) setupSystemTray(desk desktop.App) {
 		}),
 	)
 
-	desk.SetSystemTrayMenu(menu)
+	desk.SetSystemTrayMenu(c.trayMenu)
 }
 
 func (c *Client) showMainWindow() {
@@ -92,6 +195,9 @@ func (c *Client) showMainWindow() {
 		c.mainWin.Hide()
 	})
 
+	// 检查点快捷键
+	c.setupCheckpointHotkey()
+
 	c.mainWin.Show()
 }
 
@@ -102,42 +208,61 @@ func (c *Client) makeMainUI() fyne.CanvasObject {
 	// 网络状态标签
 	healthLabel := widget.NewLabel("● 已连接")
 	healthLabel.Importance = widget.SuccessImportance
-	go c.monitorHealth(healthLabel)
-
-	// 存档列表
-	savesList := widget.NewList(
-		func() int { return 0 }, // 动态加载
-		func() fyne.CanvasObject {
-			return container.NewHBox(
-				widget.NewLabel(""),
-				widget.NewButton("恢复", nil),
-				widget.NewButton("删除", nil),
-			)
-		},
-		func(id widget.ListItemID, item fyne.CanvasObject) {},
-	)
+	c.healthLabel = healthLabel
+	c.restartHealthMonitor()
+
+	// 存档浏览（战役 + 版本历史）
+	browser := c.makeSaveBrowser()
 
 	// 刷新按钮
 	refreshBtn := widget.NewButton("刷新存档列表", func() {
-		c.refreshSavesList(savesList)
+		c.refreshSavesList()
+	})
+
+	// 加载下一页
+	c.loadMoreBtn = widget.NewButton("加载更多", func() {
+		c.loadSavesPage(false)
 	})
+	c.loadMoreBtn.Disable()
+
+	// 过滤栏
+	filterBar := c.makeFilterBar()
 
 	// 设置按钮
 	settingsBtn := widget.NewButton("设置", func() {
 		c.showSettings()
 	})
 
+	// 备份清理按钮
+	retentionBtn := widget.NewButton("清理", func() {
+		c.showRetention()
+	})
+
+	// 回收站按钮
+	trashBtn := widget.NewButton("回收站", func() {
+		c.showTrash()
+	})
+
+	// 校验按钮
+	auditBtn := widget.NewButton("校验", func() {
+		c.showAudit()
+	})
+
 	// 手动上传按钮
 	uploadBtn := widget.NewButton("立即上传", func() {
 		c.manualSync()
 	})
 
 	// 自动同步开关
-	autoSyncCheck := widget.NewCheck("自动同步", func(checked bool) {
-		c.config.AutoSync = checked
-		saveConfig(c.config)
+	c.autoSyncCheck = widget.NewCheck("自动同步", func(checked bool) {
+		if checked == c.config.Load().AutoSync {
+			return
+		}
+		if err := c.updateConfig(func(cfg *Config) { cfg.AutoSync = checked }); err != nil {
+			c.statusBar.Set(fmt.Sprintf("保存设置失败: %v", err))
+		}
 	})
-	autoSyncCheck.SetChecked(c.config.AutoSync)
+	c.autoSyncCheck.SetChecked(c.config.Load().AutoSync)
 
 	// 游戏状态
 	gameStatus := widget.NewLabel("游戏状态: 未运行")
@@ -146,32 +271,149 @@ func (c *Client) makeMainUI() fyne.CanvasObject {
 	// 布局
 	toolbar := container.NewBorder(
 		nil, nil,
-		autoSyncCheck,
-		container.NewHBox(uploadBtn, settingsBtn, refreshBtn),
+		c.autoSyncCheck,
+		container.NewHBox(uploadBtn, retentionBtn, trashBtn, auditBtn, settingsBtn, refreshBtn),
 	)
 
 	return container.NewBorder(
-		toolbar,
+		container.NewVBox(toolbar, filterBar),
 		container.NewVBox(
+			c.loadMoreBtn,
+			c.makeTransferBar(),
 			container.NewHBox(gameStatus, healthLabel),
 			status,
 		),
 		nil, nil,
-		savesList,
+		browser,
+	)
+}
+
+// makeFilterBar 创建存档过滤栏，过滤在服务端完成
+func (c *Client) makeFilterBar() fyne.CanvasObject {
+	folderEntry := widget.NewEntry()
+	folderEntry.SetPlaceHolder("战役文件夹")
+
+	deviceEntry := widget.NewEntry()
+	deviceEntry.SetPlaceHolder("设备ID")
+
+	tagEntry := widget.NewEntry()
+	tagEntry.SetPlaceHolder("标签")
+
+	modeEntry := widget.NewSelectEntry([]string{"HonourMode", profileGameMode})
+	modeEntry.SetPlaceHolder("游戏模式")
+
+	sinceEntry := widget.NewDateEntry()
+	sinceEntry.SetPlaceHolder("起始日期")
+
+	untilEntry := widget.NewDateEntry()
+	untilEntry.SetPlaceHolder("结束日期")
+
+	apply := func() {
+		filter := ListSavesOptions{
+			Folder:   strings.TrimSpace(folderEntry.Text),
+			DeviceID: strings.TrimSpace(deviceEntry.Text),
+			Tag:      strings.TrimSpace(tagEntry.Text),
+			GameMode: strings.TrimSpace(modeEntry.Text),
+		}
+		if sinceEntry.Date != nil {
+			filter.Since = *sinceEntry.Date
+		}
+		if untilEntry.Date != nil {
+			// 结束日期包含当天
+			filter.Until = untilEntry.Date.AddDate(0, 0, 1)
+		}
+
+		c.savesLock.Lock()
+		c.saveFilter = filter
+		c.savesLock.Unlock()
+
+		c.refreshSavesList()
+	}
+
+	filterBtn := widget.NewButton("筛选", apply)
+	clearBtn := widget.NewButton("清除", func() {
+		folderEntry.SetText("")
+		deviceEntry.SetText("")
+		tagEntry.SetText("")
+		modeEntry.SetText("")
+		sinceEntry.SetText("")
+		untilEntry.SetText("")
+		apply()
+	})
+
+	// 搜索已加载存档的备注和标签
+	searchEntry := widget.NewEntry()
+	searchEntry.SetPlaceHolder("搜索备注、标签、存档名...")
+	searchEntry.OnChanged = func(text string) {
+		c.searchQuery = text
+
+		c.savesLock.Lock()
+		saves := c.saves
+		c.savesLock.Unlock()
+
+		c.updateSaveBrowser(saves)
+	}
+
+	return container.NewVBox(
+		container.NewBorder(
+			nil, nil, nil,
+			container.NewHBox(filterBtn, clearBtn),
+			container.NewGridWithColumns(6,
+				folderEntry, deviceEntry, tagEntry, modeEntry, sinceEntry, untilEntry,
+			),
+		),
+		searchEntry,
 	)
 }
 
-func (c *Client) refreshSavesList(list *widget.List) {
+// refreshSavesList 按当前过滤条件从第一页重新加载
+func (c *Client) refreshSavesList() {
+	c.loadSavesPage(true)
+}
+
+// loadSavesPage 加载一页存档，reset 为 false 时追加下一页
+func (c *Client) loadSavesPage(reset bool) {
+	c.savesLock.Lock()
+	opts := c.saveFilter
+	opts.Limit = savesPageSize
+	if reset {
+		// 过滤条件变化或刷新，之前未完成的请求作废
+		c.savesGen++
+	} else {
+		if c.nextCursor == "" || c.savesBusy {
+			c.savesLock.Unlock()
+			return
+		}
+		opts.Cursor = c.nextCursor
+	}
+	c.savesBusy = true
+	gen := c.savesGen
+	c.savesLock.Unlock()
+
+	// current 响应是否仍对应当前的请求，是则结束加载状态
+	current := func() bool {
+		c.savesLock.Lock()
+		defer c.savesLock.Unlock()
+		if gen != c.savesGen {
+			return false
+		}
+		c.savesBusy = false
+		return true
+	}
+
 	c.statusBar.Set("正在加载存档列表...")
 
 	go func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		saves, err := c.api.ListSaves(ctx, 100)
+		listResp, err := c.api.Load().ListSaves(ctx, opts)
 		if err != nil {
 			// UI 操作需要在主线程
 			fyne.Do(func() {
+				if !current() {
+					return
+				}
 				c.statusBar.Set(fmt.Sprintf("加载失败: %v", err))
 				dialog.ShowError(err, c.mainWin)
 			})
@@ -180,112 +422,170 @@ func (c *Client) refreshSavesList(list *widget.List) {
 
 		// 在主线程更新 UI
 		fyne.Do(func() {
-			// 更新列表数据
-			list.Length = func() int { return len(saves) }
-			list.UpdateItem = func(id widget.ListItemID, item fyne.CanvasObject) {
-				if id >= len(saves) {
-					return
-				}
-
-				save := saves[id]
-				box := item.(*fyne.Container)
-
-				label := box.Objects[0].(*widget.Label)
-				label.SetText(fmt.Sprintf("%s - %s (%s)",
-					save.Timestamp.Format("2006-01-02 15:04:05"),
-					save.FileName,
-					formatSize(save.FileSize),
-				))
+			if !current() {
+				return
+			}
+			c.savesLock.Lock()
+			if reset {
+				c.saves = nil
+			}
+			c.saves = append(c.saves, listResp.Saves...)
+			c.nextCursor = listResp.NextCursor
+			saves := c.saves
+			hasMore := c.nextCursor != ""
+			c.savesLock.Unlock()
 
-				restoreBtn := box.Objects[1].(*widget.Button)
-				restoreBtn.OnTapped = func() {
-					c.restoreSave(save)
-				}
+			c.updateSaveBrowser(saves)
 
-				deleteBtn := box.Objects[2].(*widget.Button)
-				deleteBtn.OnTapped = func() {
-					c.deleteSave(save, list)
-				}
+			if hasMore {
+				c.loadMoreBtn.Enable()
+			} else {
+				c.loadMoreBtn.Disable()
 			}
 
-			list.Refresh()
-			c.statusBar.Set(fmt.Sprintf("已加载 %d 个存档", len(saves)))
+			if listResp.Total > 0 {
+				c.statusBar.Set(fmt.Sprintf("已加载 %d / %d 个存档", len(saves), listResp.Total))
+			} else {
+				c.statusBar.Set(fmt.Sprintf("已加载 %d 个存档", len(saves)))
+			}
 		})
 	}()
 }
 
+// restoreSave 下载云端存档并与本地存档对比，确认后再恢复
 func (c *Client) restoreSave(save *SaveGame) {
-	// 确认对话框
-	dialog.ShowConfirm(
-		"确认恢复",
-		fmt.Sprintf("确定要恢复存档?\n\n时间: %s\n文件: %s\n\n当前本地存档将被覆盖!",
-			save.Timestamp.Format("2006-01-02 15:04:05"),
-			save.FileName,
-		),
-		func(ok bool) {
-			if !ok {
-				return
-			}
-
-			c.performRestore(save)
-		},
-		c.mainWin,
-	)
-}
+	if isProfileSave(save) {
+		c.restoreProfile(save)
+		return
+	}
+	if c.isOtherGameSave(save) {
+		dialog.ShowError(fmt.Errorf("该存档不属于%s，请切换到对应游戏的同步配置后再恢复", c.game().Name), c.mainWin)
+		return
+	}
 
-func (c *Client) performRestore(save *SaveGame) {
 	c.statusBar.Set("正在下载存档...")
 
 	go func() {
-		ctx := context.Background()
+		ctx, progress, end := c.beginTransfer(context.Background(), "下载", save.CampaignName())
+		defer end()
 
-		// 下载文件
-		data, err := c.api.DownloadSave(ctx, save.ID)
+		// 下载并校验文件
+		data, err := c.downloadVerified(ctx, save, progress)
+		if errors.Is(err, context.Canceled) {
+			c.statusBar.Set("已取消下载")
+			return
+		}
 		if err != nil {
-			c.statusBar.Set(fmt.Sprintf("下载失败: %v", err))
-			dialog.ShowError(err, c.mainWin)
+			fyne.Do(func() {
+				c.statusBar.Set(fmt.Sprintf("下载失败: %v", err))
+				dialog.ShowError(err, c.mainWin)
+			})
+			return
+		}
+
+		c.statusBar.Set("正在对比本地存档...")
+
+		cloud, err := snapshotFromArchive(save, data)
+		if err != nil {
+			fyne.Do(func() {
+				c.statusBar.Set(fmt.Sprintf("解析云端存档失败: %v", err))
+				dialog.ShowError(err, c.mainWin)
+			})
 			return
 		}
 
-		// 解压到本地（去掉 .zip 后缀作为文件夹名）
 		folderName := strings.TrimSuffix(save.FileName, ".zip")
-		saveFolderPath := filepath.Join(c.config.SavePath, folderName)
+		local, err := snapshotFromFolder(filepath.Join(c.saveDirFor(save), folderName))
+		if err != nil {
+			fyne.Do(func() {
+				c.statusBar.Set(fmt.Sprintf("读取本地存档失败: %v", err))
+				dialog.ShowError(err, c.mainWin)
+			})
+			return
+		}
+		if local.Exists {
+			local.Device = c.config.Load().DeviceID + "（本机）"
+			if rev := c.localRevision(save.CampaignName(), local.Time); rev != nil && local.Playtime == 0 {
+				local.Playtime = rev.GameTime
+				local.Estimated = true
+			}
+		}
 
-		// 先删除旧文件夹（如果存在）
-		os.RemoveAll(saveFolderPath)
+		// 对比存档上传时的模组与本机模组，失败不影响恢复
+		mods, err := c.compareMods(save, data, c.saveDirFor(save))
+		if err != nil {
+			log.Printf("对比模组失败: %v\n", err)
+		}
 
-		// 解压 zip 到文件夹
-		if err := unzipToFolder(data, saveFolderPath); err != nil {
-			c.statusBar.Set(fmt.Sprintf("解压失败: %v", err))
-			dialog.ShowError(err, c.mainWin)
+		fyne.Do(func() {
+			c.statusBar.Set("就绪")
+			c.showRestorePreview(save, data, local, cloud, mods)
+		})
+	}()
+}
+
+// performRestore 用已下载的存档覆盖本地存档；mods 不为空时同时恢复存档上传时的模组设置
+func (c *Client) performRestore(save *SaveGame, data []byte, mods *ModSnapshot) {
+	c.statusBar.Set("正在恢复存档...")
+
+	go func() {
+		// 解压、校验并替换本地存档
+		if _, err := c.restoreArchive(save, data); err != nil {
+			fyne.Do(func() {
+				c.statusBar.Set(fmt.Sprintf("恢复失败: %v", err))
+				dialog.ShowError(err, c.mainWin)
+			})
 			return
 		}
 
-		c.statusBar.Set("恢复成功!")
-		dialog.ShowInformation("成功", "存档已恢复到本地", c.mainWin)
+		if mods != nil {
+			if err := c.restoreModSettings(mods, c.saveDirFor(save)); err != nil {
+				fyne.Do(func() {
+					c.statusBar.Set(fmt.Sprintf("恢复模组设置失败: %v", err))
+					dialog.ShowError(fmt.Errorf("存档已恢复，但恢复模组设置失败: %w", err), c.mainWin)
+				})
+				return
+			}
+		}
+
+		fyne.Do(func() {
+			c.statusBar.Set("恢复成功!")
+			dialog.ShowInformation("成功", "存档已恢复到本地", c.mainWin)
+		})
 	}()
 }
 
-func (c *Client) deleteSave(save *SaveGame, list *widget.List) {
+func (c *Client) deleteSave(save *SaveGame) {
+	if save.Pinned {
+		dialog.ShowInformation("无法删除", ErrPinned.Error(), c.mainWin)
+		return
+	}
+
+	warning := "此操作不可恢复!"
+	if cfg := c.config.Load(); cfg.SoftDelete {
+		warning = fmt.Sprintf("存档将移入回收站，%d 天后自动清除", cfg.TrashRetentionDays)
+	}
+
 	// 确认对话框
 	dialog.ShowConfirm(
 		"确认删除",
-		fmt.Sprintf("确定要删除这个云端存档?\n\n时间: %s\n文件: %s\n\n此操作不可恢复!",
+		fmt.Sprintf("确定要删除这个云端存档?\n\n时间: %s\n文件: %s\n\n%s",
 			save.Timestamp.Format("2006-01-02 15:04:05"),
 			save.FileName,
+			warning,
 		),
 		func(ok bool) {
 			if !ok {
 				return
 			}
 
-			c.performDelete(save, list)
+			c.performDelete(save)
 		},
 		c.mainWin,
 	)
 }
 
-func (c *Client) performDelete(save *SaveGame, list *widget.List) {
+func (c *Client) performDelete(save *SaveGame) {
 	c.statusBar.Set("正在删除存档...")
 
 	go func() {
@@ -293,7 +593,7 @@ func (c *Client) performDelete(save *SaveGame, list *widget.List) {
 		defer cancel()
 
 		// 删除云端存档
-		if err := c.api.DeleteSave(ctx, save.ID); err != nil {
+		if err := c.deleteCloudSave(ctx, save); err != nil {
 			fyne.Do(func() {
 				c.statusBar.Set(fmt.Sprintf("删除失败: %v", err))
 				dialog.ShowError(err, c.mainWin)
@@ -304,12 +604,40 @@ func (c *Client) performDelete(save *SaveGame, list *widget.List) {
 		// 删除成功，刷新列表
 		fyne.Do(func() {
 			c.statusBar.Set("删除成功!")
-			c.refreshSavesList(list)
+			c.refreshSavesList()
 		})
 	}()
 }
 
+// deleteCloudSave 删除云端存档，开启回收站时移入回收站而不是直接删除。
+// 是否固定以服务端为准（拒绝删除时返回 ErrPinned），本地已知固定的存档不发送请求
+func (c *Client) deleteCloudSave(ctx context.Context, save *SaveGame) error {
+	if save.Pinned {
+		return ErrPinned
+	}
+
+	var err error
+	if c.config.Load().SoftDelete {
+		err = c.moveToTrash(ctx, save)
+	} else {
+		err = c.api.Load().DeleteSave(ctx, save.ID)
+	}
+	if errors.Is(err, ErrPinned) {
+		save.Pinned = true
+	}
+	return err
+}
+
 func (c *Client) StartWatching() error {
+	c.watchLock.Lock()
+	defer c.watchLock.Unlock()
+
+	// 重新监听时关闭旧的 watcher，旧的事件处理协程随之退出
+	if c.watcher != nil {
+		c.watcher.Close()
+		c.watcher = nil
+	}
+
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return err
@@ -319,6 +647,8 @@ func (c *Client) StartWatching() error {
 	// 监听事件处理
 	go func() {
 		debouncer := NewDebouncer(2 * time.Second)
+		// 重新监听后旧目录中还没执行的上传不再执行，避免按旧设置上传
+		defer debouncer.Stop()
 
 		for {
 			select {
@@ -342,8 +672,16 @@ func (c *Client) StartWatching() error {
 						continue
 					}
 
-					// 只处理 BG3 相关文件（.lsv 存档文件或 .WebP 截图）
-					if ext != ".lsv" && ext != ".WebP" {
+					// 新建的存档文件夹（原罪2 每次存档都会新建文件夹）在按扩展名过滤前处理
+					if event.Has(fsnotify.Create) && c.isWatchedSavePath(filepath.Dir(event.Name)) {
+						if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+							c.watchNewSaveFolder(watcher, debouncer, event.Name)
+							continue
+						}
+					}
+
+					// 只处理存档文件和截图
+					if !isSaveFileExt(ext) {
 						log.Printf("⏭️  跳过非存档文件: %s\n", fileName)
 						continue
 					}
@@ -384,32 +722,24 @@ func (c *Client) StartWatching() error {
 					log.Printf("📂 存档文件夹: %s\n", saveFolderPath)
 					// 确保是存档目录的直接子文件夹（UUID 文件夹）
 					parentDir := filepath.Dir(saveFolderPath)
-					log.Printf("📌 父目录: %s (期望: %s)\n", parentDir, c.config.SavePath)
+					log.Printf("📌 父目录: %s\n", parentDir)
 
-					if filepath.Clean(parentDir) != filepath.Clean(c.config.SavePath) {
+					if !c.isWatchedSavePath(parentDir) {
 						log.Printf("⏭️  跳过: 不是直接子文件夹\n")
 						continue
 					}
 
-					// 只处理 __HonourMode 后缀的文件夹
+					// 只处理要同步的游戏模式的文件夹
 					folderName := filepath.Base(saveFolderPath)
 					log.Printf("📝 文件夹名: %s\n", folderName)
-					if !strings.HasSuffix(folderName, "__HonourMode") {
-						log.Printf("⏭️  跳过: 不是荣誉模式存档\n")
+					if !c.isSyncedFolder(folderName) {
+						log.Printf("⏭️  跳过: 不是要同步的游戏模式\n")
 						continue
 					}
 
-					// 如果检测到新创建的文件夹，添加到监听列表
-					if event.Has(fsnotify.Create) {
-						if info, err := os.Stat(saveFolderPath); err == nil && info.IsDir() {
-							log.Printf("检测到新存档文件夹，添加监听: %s\n", saveFolderPath)
-							watcher.Add(saveFolderPath)
-						}
-					}
-
 					// 只在开启自动同步且游戏运行时上传
-					log.Printf("🔧 AutoSync: %v\n", c.config.AutoSync)
-					if !c.config.AutoSync || !c.gameRunning {
+					log.Printf("🔧 AutoSync: %v\n", c.config.Load().AutoSync)
+					if !c.config.Load().AutoSync || !c.gameRunning.Load() {
 						log.Printf("⏭️  跳过: 自动同步未开启or游戏未运行\n")
 						continue
 					}
@@ -431,44 +761,88 @@ func (c *Client) StartWatching() error {
 		}
 	}()
 
-	// 添加主目录监听
-	if err := watcher.Add(c.config.SavePath); err != nil {
-		return err
-	}
+	// 监听每个选择的玩家档案的存档目录
+	for _, savePath := range c.watchedSavePaths() {
+		if err := watcher.Add(savePath); err != nil {
+			log.Printf("添加存档目录监听失败 %s: %v\n", savePath, err)
+			continue
+		}
+		log.Printf("已监听玩家档案 %s: %s\n", playerProfileOf(savePath), savePath)
 
-	// 递归添加所有已存在的 __HonourMode 子目录到监听列表
-	entries, err := os.ReadDir(c.config.SavePath)
-	if err != nil {
-		log.Printf("读取存档目录失败: %v\n", err)
-		return nil // 不返回错误，继续运行
-	}
+		// 递归添加所有已存在的要同步的子目录到监听列表
+		entries, err := os.ReadDir(savePath)
+		if err != nil {
+			log.Printf("读取存档目录失败: %v\n", err)
+			continue // 不返回错误，继续运行
+		}
 
-	for _, entry := range entries {
-		if entry.IsDir() && strings.HasSuffix(entry.Name(), "__HonourMode") {
-			subDir := filepath.Join(c.config.SavePath, entry.Name())
-			if err := watcher.Add(subDir); err != nil {
-				log.Printf("添加子目录监听失败 %s: %v\n", subDir, err)
-			} else {
-				log.Printf("已添加存档目录监听: %s\n", entry.Name())
+		for _, entry := range entries {
+			if entry.IsDir() && c.isSyncedFolder(entry.Name()) {
+				subDir := filepath.Join(savePath, entry.Name())
+				if err := watcher.Add(subDir); err != nil {
+					log.Printf("添加子目录监听失败 %s: %v\n", subDir, err)
+				} else {
+					log.Printf("已添加存档目录监听: %s\n", entry.Name())
+				}
 			}
 		}
+
+		// 提前在后台计算 Mods 清单，第一次上传时就能附带
+		c.modManifests.get(modsDir(savePath))
 	}
 
 	return nil
 }
 
-func (c *Client) handleSaveFolder(folderPath string) {
+// watchNewSaveFolder 监听新建的存档文件夹。添加监听前文件夹中可能已经写入了存档文件，
+// 因此同时安排一次上传，之后的文件事件会重新计时
+func (c *Client) watchNewSaveFolder(watcher *fsnotify.Watcher, debouncer *Debouncer, folderPath string) {
+	folderName := filepath.Base(folderPath)
+	// 恢复存档时临时移到一旁的旧存档不需要监听
+	if filepath.Ext(folderName) == ".old" || !c.isSyncedFolder(folderName) {
+		return
+	}
+
+	log.Printf("检测到新存档文件夹，添加监听: %s\n", folderPath)
+	if err := watcher.Add(folderPath); err != nil {
+		log.Printf("添加子目录监听失败 %s: %v\n", folderPath, err)
+		return
+	}
+
+	if !c.config.Load().AutoSync || !c.gameRunning.Load() {
+		return
+	}
+	debouncer.Do(func() {
+		c.handleSaveFolder(folderPath)
+	})
+}
+
+// handleSaveFolder 打包并上传存档文件夹，失败时返回 nil
+func (c *Client) handleSaveFolder(folderPath string) *SaveGame {
 	folderName := filepath.Base(folderPath)
 	c.statusBar.Set(fmt.Sprintf("正在上传: %s", folderName))
 
 	log.Printf("检测到存档变化: %s\n", folderPath)
 
+	// 解析存档信息，失败不影响上传
+	info, err := readSaveInfo(folderPath)
+	if err != nil {
+		log.Printf("解析存档信息失败: %v\n", err)
+	} else {
+		info.Game = c.game().ID
+	}
+
 	// 打包文件夹为 zip
 	zipData, err := zipFolder(folderPath)
 	if err != nil {
 		log.Printf("打包文件夹失败: %v\n", err)
 		c.statusBar.Set(fmt.Sprintf("打包失败: %v", err))
-		return
+		return nil
+	}
+
+	// 附带当前的模组设置，恢复时用于检查加载顺序
+	if info != nil {
+		info.Mods = c.uploadModSnapshot(filepath.Dir(folderPath))
 	}
 
 	// 显示压缩后的文件大小
@@ -477,15 +851,26 @@ func (c *Client) handleSaveFolder(folderPath string) {
 	c.statusBar.Set(fmt.Sprintf("正在上传: %s (%s)", folderName, formatSize(int64(zipSize))))
 
 	// 上传 zip 文件
-	ctx := context.Background()
-	save, err := c.api.UploadSave(ctx, folderName+".zip", zipData)
+	ctx, progress, end := c.beginTransfer(context.Background(), "上传", folderName)
+	defer end()
+
+	save, err := c.api.Load().UploadSave(ctx, folderName+".zip", zipData, info, progress)
+	if errors.Is(err, context.Canceled) {
+		log.Printf("已取消上传: %s\n", folderName)
+		c.statusBar.Set(fmt.Sprintf("已取消上传: %s", folderName))
+		return nil
+	}
 	if err != nil {
 		log.Printf("上传失败: %v\n", err)
 
-		// 检查是否是文件过大错误
 		errMsg := err.Error()
-		if strings.Contains(errMsg, "413") || strings.Contains(errMsg, "Request Entity Too Large") {
+		switch {
+		case errors.Is(err, ErrTooLarge):
 			errMsg = fmt.Sprintf("文件太大 (%s)，请增加 nginx 的 client_max_body_size 配置", formatSize(int64(zipSize)))
+		case errors.Is(err, ErrUnauthorized):
+			errMsg = "服务器拒绝访问，请检查服务器配置"
+		case errors.Is(err, ErrNetwork):
+			errMsg = "无法连接服务器，请检查网络"
 		}
 
 		c.statusBar.Set(fmt.Sprintf("上传失败: %s", errMsg))
@@ -493,12 +878,14 @@ func (c *Client) handleSaveFolder(folderPath string) {
 			Title:   "存档同步",
 			Content: "上传失败: " + errMsg,
 		})
-		return
+		return nil
 	}
 
 	// 记录最后一次上传信息，用于处理游戏退出时的自动保存
+	c.uploadLock.Lock()
 	c.lastUploadedSave = save
 	c.lastUploadTime = time.Now()
+	c.uploadLock.Unlock()
 
 	msg := fmt.Sprintf("已备份: %s", folderName)
 	c.statusBar.Set(msg)
@@ -508,26 +895,37 @@ func (c *Client) handleSaveFolder(folderPath string) {
 		Title:   "BG3 存档同步",
 		Content: msg,
 	})
+
+	cfg := c.config.Load()
+	if targets := cfg.mirrorTargets(); len(targets) > 0 {
+		upload, download := cfg.Bandwidth.Rates(c.gameRunning.Load())
+		go c.mirrorUpload(targets, upload, download, folderName+".zip", zipData, info)
+	}
+
+	go c.syncProfile(filepath.Dir(folderPath))
+	c.maybeAutoRetention()
+	return save
 }
 
 func (c *Client) monitorGameProcess(label *widget.Label) {
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
+	// 按当前游戏的进程名匹配，切换游戏后立即生效
+	match := func(p Process) bool {
+		return matchProcess(p, c.game().Processes())
+	}
 
-	for range ticker.C {
-		var running bool
-		if runtime.GOOS == "windows" {
-			running = isProcessRunning("bg3.exe") || isProcessRunning("bg3_dx11.exe")
-		} else if runtime.GOOS == "darwin" {
-			// macOS 上 BG3 的进程名（可能需要根据实际情况调整）
-			running = isProcessRunning("Baldur's Gate 3")
+	pids := make(map[int]bool)
+	for event := range newProcessWatcher().Watch(context.Background(), match) {
+		if event.Started {
+			log.Printf("检测到游戏进程启动: %s (PID %d)\n", event.Name, event.PID)
+			pids[event.PID] = true
 		} else {
-			// Linux
-			running = isProcessRunning("bg3") || isProcessRunning("bg3.bin")
+			log.Printf("游戏进程已退出: %s (PID %d)\n", event.Name, event.PID)
+			delete(pids, event.PID)
 		}
 
-		if running != c.gameRunning {
-			c.gameRunning = running
+		running := len(pids) > 0
+		if c.gameRunning.Swap(running) != running {
+			c.applyBandwidthLimits()
 			if running {
 				// 在主 UI 线程中更新 label
 				fyne.Do(func() {
@@ -540,11 +938,11 @@ func (c *Client) monitorGameProcess(label *widget.Label) {
 				})
 
 				// 游戏退出时，删除最近10秒内上传的存档（这是游戏的自动保存）
-				if c.lastUploadedSave != nil && time.Since(c.lastUploadTime) < 10*time.Second {
+				if save, uploaded := c.lastUpload(); save != nil && time.Since(uploaded) < 10*time.Second {
 					go c.deleteLastAutoSave()
 				}
 				// 游戏退出时，询问是否下载最新存档
-				if c.config.AutoSync {
+				if c.config.Load().AutoSync {
 					c.checkForNewerSaves()
 				}
 			}
@@ -554,7 +952,7 @@ func (c *Client) monitorGameProcess(label *widget.Label) {
 
 func (c *Client) checkForNewerSaves() {
 	// 检查是否开启自动恢复
-	if !c.config.AutoRestore {
+	if !c.config.Load().AutoRestore {
 		return
 	}
 
@@ -567,7 +965,7 @@ func (c *Client) checkForNewerSaves() {
 
 		// 获取云端最新存档
 		ctx := context.Background()
-		latestSave, err := c.api.GetLatestSave(ctx)
+		latestSave, err := c.latestGameSave(ctx)
 		if err != nil {
 			log.Printf("获取云端最新存档失败: %v\n", err)
 			c.statusBar.Set(fmt.Sprintf("获取云端存档失败: %v", err))
@@ -575,7 +973,7 @@ func (c *Client) checkForNewerSaves() {
 		}
 
 		// 检查是否是刚才被删除的存档（通过比较ID）
-		if c.lastUploadedSave != nil && latestSave.ID == c.lastUploadedSave.ID {
+		if last, _ := c.lastUpload(); last != nil && latestSave.ID == last.ID {
 			log.Printf("云端最新存档是刚才删除的自动保存，跳过恢复\n")
 			c.statusBar.Set("无需恢复")
 			return
@@ -585,28 +983,38 @@ func (c *Client) checkForNewerSaves() {
 
 		// 自动下载并恢复
 		c.statusBar.Set("正在自动恢复云端存档...")
-		data, err := c.api.DownloadSave(ctx, latestSave.ID)
+		ctx, progress, end := c.beginTransfer(ctx, "下载", latestSave.CampaignName())
+		data, err := c.downloadVerified(ctx, latestSave, progress)
+		end()
+		if errors.Is(err, context.Canceled) {
+			log.Printf("已取消自动恢复\n")
+			c.statusBar.Set("已取消自动恢复")
+			return
+		}
 		if err != nil {
 			log.Printf("下载云端存档失败: %v\n", err)
 			c.statusBar.Set(fmt.Sprintf("下载失败: %v", err))
 			return
 		}
 
-		// 解压到本地
-		folderName := strings.TrimSuffix(latestSave.FileName, ".zip")
-		saveFolderPath := filepath.Join(c.config.SavePath, folderName)
-
-		// 先删除旧文件夹（如果存在）
-		os.RemoveAll(saveFolderPath)
-
-		// 解压
-		if err := unzipToFolder(data, saveFolderPath); err != nil {
-			log.Printf("解压失败: %v\n", err)
-			c.statusBar.Set(fmt.Sprintf("解压失败: %v", err))
+		// 解压、校验并替换本地存档
+		folderName, err := c.restoreArchive(latestSave, data)
+		if err != nil {
+			log.Printf("自动恢复失败: %v\n", err)
+			c.statusBar.Set(fmt.Sprintf("自动恢复失败: %v", err))
 			return
 		}
 
 		msg := fmt.Sprintf("已自动恢复云端存档: %s", folderName)
+
+		// 自动恢复不修改模组设置，只提示差异
+		if mods, err := c.compareMods(latestSave, data, c.saveDirFor(latestSave)); err != nil {
+			log.Printf("对比模组失败: %v\n", err)
+		} else if mods != nil && len(mods.Problems) > 0 {
+			log.Printf("⚠️  存档的模组与本机不一致: %s\n", strings.Join(mods.Problems, "; "))
+			msg += "（模组与本机不一致，请在主界面恢复该存档以查看差异）"
+		}
+
 		c.statusBar.Set(msg)
 		log.Printf("%s\n", msg)
 
@@ -618,20 +1026,49 @@ func (c *Client) checkForNewerSaves() {
 	}()
 }
 
+// lastUpload 返回最后一次上传的存档和上传时间
+func (c *Client) lastUpload() (*SaveGame, time.Time) {
+	c.uploadLock.Lock()
+	defer c.uploadLock.Unlock()
+	return c.lastUploadedSave, c.lastUploadTime
+}
+
+// replaceLastUpload 最后一次上传的仍是 old 时替换为 next（nil 表示清空），
+// 期间又上传了新的存档时保留新的记录
+func (c *Client) replaceLastUpload(old, next *SaveGame) {
+	c.uploadLock.Lock()
+	defer c.uploadLock.Unlock()
+	if c.lastUploadedSave == old {
+		c.lastUploadedSave = next
+	}
+}
+
 func (c *Client) deleteLastAutoSave() {
-	if c.lastUploadedSave == nil {
+	save, uploaded := c.lastUpload()
+	if save == nil {
 		return
 	}
 
-	saveID := c.lastUploadedSave.ID
-	fileName := c.lastUploadedSave.FileName
-	timeSinceUpload := time.Since(c.lastUploadTime)
+	// 检查点是玩家主动创建的，即使在退出前刚刚创建也不删除
+	if save.IsCheckpoint() {
+		log.Printf("最近上传的是检查点，保留: %s\n", save.FileName)
+		c.replaceLastUpload(save, nil)
+		return
+	}
+
+	fileName := save.FileName
+	timeSinceUpload := time.Since(uploaded)
 
 	log.Printf("检测到游戏退出，删除最近上传的自动保存: %s (上传于 %.1f 秒前)\n",
 		fileName, timeSinceUpload.Seconds())
 
 	ctx := context.Background()
-	if err := c.api.DeleteSave(ctx, saveID); err != nil {
+	if err := c.deleteCloudSave(ctx, save); err != nil {
+		if errors.Is(err, ErrPinned) {
+			log.Printf("自动保存已被固定，保留: %s\n", fileName)
+			c.replaceLastUpload(save, nil)
+			return
+		}
 		log.Printf("删除自动保存失败: %v\n", err)
 		c.statusBar.Set(fmt.Sprintf("删除自动保存失败: %v", err))
 		return
@@ -641,33 +1078,36 @@ func (c *Client) deleteLastAutoSave() {
 	c.statusBar.Set("已删除游戏退出时的自动保存")
 
 	// 清空记录
-	c.lastUploadedSave = nil
+	c.replaceLastUpload(save, nil)
 }
 
 func (c *Client) manualSync() {
 	c.statusBar.Set("正在手动同步...")
 	// 扫描所有 UUID 文件夹并上传
 	go func() {
-		entries, err := os.ReadDir(c.config.SavePath)
-		if err != nil {
-			log.Printf("读取存档目录失败: %v\n", err)
-			c.statusBar.Set(fmt.Sprintf("读取目录失败: %v", err))
-			return
-		}
-
 		count := 0
-		for _, entry := range entries {
-			if entry.IsDir() {
-				// 只处理 __HonourMode 后缀的文件夹
-				if !strings.HasSuffix(entry.Name(), "__HonourMode") {
-					continue
-				}
+		for _, savePath := range c.watchedSavePaths() {
+			entries, err := os.ReadDir(savePath)
+			if err != nil {
+				log.Printf("读取存档目录失败: %v\n", err)
+				c.statusBar.Set(fmt.Sprintf("读取目录失败: %v", err))
+				continue
+			}
 
-				folderPath := filepath.Join(c.config.SavePath, entry.Name())
-				c.handleSaveFolder(folderPath)
-				count++
-				time.Sleep(500 * time.Millisecond)
+			for _, entry := range entries {
+				if entry.IsDir() {
+					// 只处理要同步的游戏模式的文件夹
+					if !c.isSyncedFolder(entry.Name()) {
+						continue
+					}
+
+					folderPath := filepath.Join(savePath, entry.Name())
+					c.handleSaveFolder(folderPath)
+					count++
+					time.Sleep(500 * time.Millisecond)
+				}
 			}
+			c.syncProfile(savePath)
 		}
 		c.statusBar.Set(fmt.Sprintf("手动同步完成，已上传 %d 个存档", count))
 	}()
@@ -675,14 +1115,15 @@ func (c *Client) manualSync() {
 
 func (c *Client) showSettings() {
 	win := c.app.NewWindow("设置")
-	win.Resize(fyne.NewSize(500, 400))
+	win.Resize(fyne.NewSize(550, 600))
+	current := c.config.Load()
 
 	// 配置项
 	nebulaURL := widget.NewEntry()
-	nebulaURL.SetText(c.config.NebulaURL)
+	nebulaURL.SetText(current.NebulaURL)
 
 	savePath := widget.NewEntry()
-	savePath.SetText(c.config.SavePath)
+	savePath.SetText(current.SavePath)
 
 	browseBtn := widget.NewButton("浏览...", func() {
 		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
@@ -692,20 +1133,306 @@ func (c *Client) showSettings() {
 		}, win)
 	})
 
+	// 监听的玩家档案
+	watchedProfiles := current.PlayerProfiles
+	if len(watchedProfiles) == 0 {
+		watchedProfiles = []string{playerProfileOf(current.SavePath)}
+	}
+	playerProfiles := widget.NewCheckGroup(detectPlayerProfiles(current.SavePath), nil)
+	playerProfiles.SetSelected(watchedProfiles)
+	playerProfiles.Horizontal = true
+
+	// 修改存档路径（包括切换游戏）后重新列出该路径下的玩家档案，保留仍然存在的选择
+	savePath.OnChanged = func(path string) {
+		options := detectPlayerProfiles(path)
+		var selected []string
+		for _, name := range playerProfiles.Selected {
+			if slices.Contains(options, name) {
+				selected = append(selected, name)
+			}
+		}
+		if len(selected) == 0 {
+			selected = []string{playerProfileOf(path)}
+		}
+		playerProfiles.Options = options
+		playerProfiles.SetSelected(selected)
+	}
+
+	gameModes := widget.NewEntry()
+	gameModes.SetPlaceHolder("例如 HonourMode, Classic，留空同步所有模式")
+	gameModes.SetText(strings.Join(current.GameModes, ", "))
+
+	// 切换游戏时改用该游戏的默认存档路径和游戏模式
+	gameSelect := widget.NewSelect(gameNames(), nil)
+	gameSelect.SetSelected(c.game().Name)
+	gameSelect.OnChanged = func(name string) {
+		g := gameByName(name)
+		savePath.SetText(g.DefaultSavePath())
+		gameModes.SetText(strings.Join(g.DefaultModes, ", "))
+	}
+
+	syncProfilesBtn := widget.NewButton("管理同步配置...", func() {
+		win.Close()
+		c.showSyncProfiles()
+	})
+
 	autoUpload := widget.NewCheck("游戏运行时自动上传", nil)
-	autoUpload.SetChecked(c.config.AutoUpload)
+	autoUpload.SetChecked(current.AutoUpload)
 
 	autoRestore := widget.NewCheck("游戏退出后自动恢复云端存档", nil)
-	autoRestore.SetChecked(c.config.AutoRestore)
+	autoRestore.SetChecked(current.AutoRestore)
+
+	softDelete := widget.NewCheck("删除的云端存档先移入回收站", nil)
+	softDelete.SetChecked(current.SoftDelete)
+
+	trashDays := widget.NewEntry()
+	trashDays.SetText(strconv.Itoa(current.TrashRetentionDays))
+
+	auditInterval := widget.NewEntry()
+	auditInterval.SetText(strconv.Itoa(current.AuditIntervalHours))
+
+	auditFull := widget.NewCheck("定期校验时下载并校验完整内容", nil)
+	auditFull.SetChecked(current.AuditFullDownload)
+
+	checkpointHotkey := widget.NewEntry()
+	checkpointHotkey.SetPlaceHolder("例如 Ctrl+Shift+F9，留空禁用")
+	checkpointHotkey.SetText(current.CheckpointHotkey)
+
+	// 限速 (KB/s)
+	bandwidth := current.Bandwidth
+	uploadLimit := widget.NewEntry()
+	uploadLimit.SetText(strconv.Itoa(bandwidth.UploadKBps))
+	downloadLimit := widget.NewEntry()
+	downloadLimit.SetText(strconv.Itoa(bandwidth.DownloadKBps))
+	gameUploadLimit := widget.NewEntry()
+	gameUploadLimit.SetText(strconv.Itoa(bandwidth.GameUploadKBps))
+	gameDownloadLimit := widget.NewEntry()
+	gameDownloadLimit.SetText(strconv.Itoa(bandwidth.GameDownloadKBps))
+
+	// 网络：代理、证书和超时
+	network := current.Network
+	proxyURL := widget.NewEntry()
+	proxyURL.SetPlaceHolder("例如 socks5://127.0.0.1:1080，留空使用系统代理")
+	proxyURL.SetText(network.ProxyURL)
+	caFile := widget.NewEntry()
+	caFile.SetText(network.CAFile)
+	clientCert := widget.NewEntry()
+	clientCert.SetText(network.ClientCert)
+	clientKey := widget.NewEntry()
+	clientKey.SetText(network.ClientKey)
+	connectTimeout := widget.NewEntry()
+	connectTimeout.SetText(strconv.Itoa(network.Timeouts.ConnectSeconds))
+	requestTimeout := widget.NewEntry()
+	requestTimeout.SetText(strconv.Itoa(network.Timeouts.RequestSeconds))
+	transferTimeout := widget.NewEntry()
+	transferTimeout.SetText(strconv.Itoa(network.Timeouts.TransferMinutes))
+	compression := widget.NewCheck("压缩上传的请求 (gzip，仅在服务端声明支持时)", nil)
+	compression.SetChecked(network.Compression)
+
+	// 玩家档案同步
+	var profileOptions []string
+	var profileSelected []string
+	for _, item := range profileItems {
+		profileOptions = append(profileOptions, item.Name)
+		if current.ProfileSync.Has(item.Key) {
+			profileSelected = append(profileSelected, item.Name)
+		}
+	}
+	profileItemsGroup := widget.NewCheckGroup(profileOptions, nil)
+	profileItemsGroup.SetSelected(profileSelected)
+	profileSync := widget.NewCheck("同步玩家档案 (PlayerProfiles/Public)", func(checked bool) {
+		if checked {
+			profileItemsGroup.Enable()
+		} else {
+			profileItemsGroup.Disable()
+		}
+	})
+	profileSync.SetChecked(current.ProfileSync.Enabled)
+	if !profileSync.Checked {
+		profileItemsGroup.Disable()
+	}
+
+	browseFile := func(entry *widget.Entry) *widget.Button {
+		return widget.NewButton("浏览...", func() {
+			dialog.ShowFileOpen(func(file fyne.URIReadCloser, err error) {
+				if file != nil {
+					entry.SetText(file.URI().Path())
+					file.Close()
+				}
+			}, win)
+		})
+	}
+
+	// readNetwork 读取表单中的网络设置
+	readNetwork := func() (NetworkConfig, error) {
+		cfg := NetworkConfig{
+			ProxyURL:   strings.TrimSpace(proxyURL.Text),
+			CAFile:     strings.TrimSpace(caFile.Text),
+			ClientCert: strings.TrimSpace(clientCert.Text),
+			ClientKey:  strings.TrimSpace(clientKey.Text),
+
+			Compression: compression.Checked,
+		}
+
+		timeouts := []struct {
+			entry *widget.Entry
+			value *int
+		}{
+			{connectTimeout, &cfg.Timeouts.ConnectSeconds},
+			{requestTimeout, &cfg.Timeouts.RequestSeconds},
+			{transferTimeout, &cfg.Timeouts.TransferMinutes},
+		}
+		for _, timeout := range timeouts {
+			value, err := strconv.Atoi(strings.TrimSpace(timeout.entry.Text))
+			if err != nil || value <= 0 {
+				return cfg, fmt.Errorf("超时必须是正整数")
+			}
+			*timeout.value = value
+		}
+
+		if cfg.ProxyURL != "" {
+			if _, err := parseProxyURL(cfg.ProxyURL); err != nil {
+				return cfg, err
+			}
+		}
+		return cfg, nil
+	}
+
+	// 测试连接，逐步显示结果
+	testBtn := widget.NewButton("测试连接", nil)
+	testBtn.OnTapped = func() {
+		cfg, err := readNetwork()
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+
+		testBtn.Disable()
+		baseURL := strings.TrimSpace(nebulaURL.Text)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeouts.Connect()+cfg.Timeouts.Request())
+			defer cancel()
+
+			steps := testConnection(ctx, baseURL, c.config.Load().DeviceID, cfg)
+
+			var lines []string
+			failed := false
+			for _, step := range steps {
+				if step.Err != nil {
+					failed = true
+					lines = append(lines, fmt.Sprintf("✗ %s: %v", step.Name, step.Err))
+				} else {
+					lines = append(lines, "✓ "+step.Name)
+				}
+			}
+
+			fyne.Do(func() {
+				testBtn.Enable()
+				title := "连接成功"
+				if failed {
+					title = "连接失败"
+				}
+				dialog.ShowInformation(title, strings.Join(lines, "\n"), win)
+			})
+		}()
+	}
 
 	// 保存按钮
 	saveBtn := widget.NewButton("保存", func() {
-		c.config.NebulaURL = nebulaURL.Text
-		c.config.SavePath = savePath.Text
-		c.config.AutoUpload = autoUpload.Checked
-		c.config.AutoRestore = autoRestore.Checked
+		days, err := strconv.Atoi(strings.TrimSpace(trashDays.Text))
+		if err != nil || days < 0 {
+			dialog.ShowError(fmt.Errorf("回收站保留天数必须是非负整数"), win)
+			return
+		}
 
-		if err := saveConfig(c.config); err != nil {
+		auditHours, err := strconv.Atoi(strings.TrimSpace(auditInterval.Text))
+		if err != nil || auditHours < 0 {
+			dialog.ShowError(fmt.Errorf("校验间隔必须是非负整数"), win)
+			return
+		}
+
+		limits := []struct {
+			entry *widget.Entry
+			value *int
+		}{
+			{uploadLimit, &bandwidth.UploadKBps},
+			{downloadLimit, &bandwidth.DownloadKBps},
+			{gameUploadLimit, &bandwidth.GameUploadKBps},
+			{gameDownloadLimit, &bandwidth.GameDownloadKBps},
+		}
+		for _, limit := range limits {
+			kbps, err := strconv.Atoi(strings.TrimSpace(limit.entry.Text))
+			if err != nil || kbps < 0 {
+				dialog.ShowError(fmt.Errorf("限速必须是非负整数 (KB/s)"), win)
+				return
+			}
+			*limit.value = kbps
+		}
+
+		newNetwork, err := readNetwork()
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		if _, err := buildTransport(newNetwork); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+
+		hotkey := strings.TrimSpace(checkpointHotkey.Text)
+		if hotkey != "" {
+			hk, err := parseHotkey(hotkey)
+			if err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+			hotkey = hk.String()
+		}
+
+		modes := []string{}
+		for _, mode := range strings.Split(gameModes.Text, ",") {
+			if mode = strings.TrimSpace(mode); mode != "" {
+				modes = append(modes, mode)
+			}
+		}
+
+		game := gameByName(gameSelect.Selected)
+		next := c.config.Load().clone()
+		next.Game = game.ID
+		next.NebulaURL = strings.TrimSpace(nebulaURL.Text)
+		next.SavePath = savePath.Text
+		next.AutoUpload = autoUpload.Checked
+		next.AutoRestore = autoRestore.Checked
+		next.SoftDelete = softDelete.Checked
+		next.TrashRetentionDays = days
+		next.AuditIntervalHours = auditHours
+		next.AuditFullDownload = auditFull.Checked
+		next.Bandwidth = bandwidth
+		next.ProfileSync = ProfileSyncConfig{Enabled: profileSync.Checked, Items: []string{}}
+		for _, item := range profileItems {
+			for _, selected := range profileItemsGroup.Selected {
+				if selected == item.Name {
+					next.ProfileSync.Items = append(next.ProfileSync.Items, item.Key)
+				}
+			}
+		}
+		next.PlayerProfiles = playerProfiles.Selected
+		next.GameModes = modes
+		next.Network = newNetwork
+		next.CheckpointHotkey = hotkey
+
+		if err := next.Validate(); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		// 只在修改存档路径时检查目录，游戏还没创建存档目录时仍然可以保存其他设置
+		if next.SavePath != c.config.Load().SavePath {
+			if err := validateWritableDir(next.SavePath); err != nil {
+				dialog.ShowError(fmt.Errorf("存档路径无效: %w", err), win)
+				return
+			}
+		}
+		if err := c.updateConfig(func(cfg *Config) { *cfg = *next }); err != nil {
 			dialog.ShowError(err, win)
 			return
 		}
@@ -715,24 +1442,58 @@ func (c *Client) showSettings() {
 	})
 
 	form := container.NewVBox(
+		container.NewBorder(nil, nil, nil, syncProfilesBtn, widget.NewLabel(c.activeProfileLabel())),
+		container.NewBorder(nil, nil, widget.NewLabel("游戏:"), nil, gameSelect),
 		widget.NewLabel("Nebula 服务器地址:"),
 		nebulaURL,
 		widget.NewLabel(""),
 		widget.NewLabel("存档路径:"),
 		container.NewBorder(nil, nil, nil, browseBtn, savePath),
+		widget.NewLabel("监听的玩家档案:"),
+		playerProfiles,
+		container.NewBorder(nil, nil, widget.NewLabel("同步的游戏模式:"), nil, gameModes),
 		widget.NewLabel(""),
 		autoUpload,
 		autoRestore,
+		softDelete,
+		container.NewBorder(nil, nil, widget.NewLabel("回收站保留天数 (0 为不自动清除):"), nil, trashDays),
+		container.NewBorder(nil, nil, widget.NewLabel("检查点快捷键:"), nil, checkpointHotkey),
+		container.NewBorder(nil, nil, widget.NewLabel("云端校验间隔 (小时，0 为不自动校验):"), nil, auditInterval),
+		auditFull,
+		widget.NewLabel(""),
+		profileSync,
+		profileItemsGroup,
+		widget.NewLabel(""),
+		widget.NewLabel("限速 (KB/s，0 为不限速):"),
+		container.NewGridWithColumns(4,
+			widget.NewLabel("上传"), uploadLimit,
+			widget.NewLabel("下载"), downloadLimit,
+			widget.NewLabel("游戏运行时上传"), gameUploadLimit,
+			widget.NewLabel("游戏运行时下载"), gameDownloadLimit,
+		),
+		widget.NewLabel(""),
+		widget.NewLabel("网络:"),
+		container.NewBorder(nil, nil, widget.NewLabel("代理:"), nil, proxyURL),
+		container.NewBorder(nil, nil, widget.NewLabel("CA 证书:"), browseFile(caFile), caFile),
+		container.NewBorder(nil, nil, widget.NewLabel("客户端证书:"), browseFile(clientCert), clientCert),
+		container.NewBorder(nil, nil, widget.NewLabel("客户端私钥:"), browseFile(clientKey), clientKey),
+		container.NewGridWithColumns(6,
+			widget.NewLabel("连接超时 (秒)"), connectTimeout,
+			widget.NewLabel("请求超时 (秒)"), requestTimeout,
+			widget.NewLabel("传输超时 (分钟)"), transferTimeout,
+		),
+		compression,
+		testBtn,
 		widget.NewLabel(""),
 		saveBtn,
 	)
 
-	win.SetContent(container.NewPadded(form))
+	win.SetContent(container.NewVScroll(container.NewPadded(form)))
 	win.Show()
 }
 
-// monitorHealth 定时检查服务器健康状态
-func (c *Client) monitorHealth(label *widget.Label) {
+// monitorHealth 定时检查服务器健康状态，直到 ctx 取消
+func (c *Client) monitorHealth(ctx context.Context, label *widget.Label) {
 	// 首次立即检查
 	c.checkHealthOnce(label)
 
@@ -740,8 +1501,13 @@ func (c *Client) monitorHealth(label *widget.Label) {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		c.checkHealthOnce(label)
+	for {
+		select {
+		case <-ticker.C:
+			c.checkHealthOnce(label)
+		case <-ctx.Done():
+			return
+		}
 	}
 }
 
@@ -750,7 +1516,7 @@ func (c *Client) checkHealthOnce(label *widget.Label) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	err := c.api.CheckHealth(ctx)
+	err := c.api.Load().CheckHealth(ctx)
 
 	c.healthLock.Lock()
 	c.lastHealthStatus = c.healthStatus
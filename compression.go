@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// 小于该大小的请求体不压缩。请求体只支持 gzip（标准库自带）；
+// zstd 需要引入第三方库，暂不支持，服务端只声明 zstd 时不压缩
+const compressMinSize = 1024
+
+// gzipBytes 以最快速度压缩数据
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer, err := gzip.NewWriterLevel(&buf, gzip.BestSpeed)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// compressBody 启用压缩且服务端声明支持 gzip 请求体后才压缩，只有压缩后更小才使用
+func (api *NebulaAPI) compressBody(r *apiRequest) {
+	if !api.compress || !api.gzipAccepted.Load() || api.gzipRejected.Load() || len(r.body) < compressMinSize {
+		return
+	}
+
+	compressed, err := gzipBytes(r.body)
+	if err != nil || len(compressed) >= len(r.body) {
+		return
+	}
+
+	r.plainBody = r.body
+	r.body = compressed
+	r.encoding = "gzip"
+}
+
+// rejectCompression 服务端不接受压缩的请求体（415），之后的请求都不再压缩
+func (api *NebulaAPI) rejectCompression(r *apiRequest) {
+	if !api.gzipRejected.Swap(true) {
+		log.Printf("服务端不支持 gzip 请求体，关闭请求压缩\n")
+	}
+	r.body = r.plainBody
+	r.plainBody = nil
+	r.encoding = ""
+}
+
+// noteAcceptEncoding 记录服务端最近一次在响应中声明支持的请求编码（RFC 7694），
+// 没有声明 gzip 时不压缩请求体，之后重新声明时恢复压缩
+func (api *NebulaAPI) noteAcceptEncoding(header http.Header) {
+	accept := header.Values("Accept-Encoding")
+	if len(accept) == 0 {
+		return
+	}
+	gzipAccepted := false
+	for _, value := range accept {
+		if strings.Contains(strings.ToLower(value), "gzip") {
+			gzipAccepted = true
+			break
+		}
+	}
+	if api.gzipAccepted.Swap(gzipAccepted) != gzipAccepted {
+		if gzipAccepted {
+			log.Printf("服务端支持 gzip 请求体，开始压缩请求\n")
+		} else {
+			log.Printf("服务端不再声明支持 gzip 请求体，暂停压缩请求\n")
+		}
+	}
+}
+
+// gzipBody 解压响应体，关闭时同时关闭原始响应体
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (g *gzipBody) Close() error {
+	g.Reader.Close()
+	return g.body.Close()
+}
+
+// decodeResponse 解压 gzip 编码的响应体
+func decodeResponse(resp *http.Response) error {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return nil
+	}
+
+	reader, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return err
+	}
+	resp.Body = &gzipBody{Reader: reader, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.ContentLength = -1
+	return nil
+}
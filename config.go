@@ -0,0 +1,326 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// 当前配置文件版本，修改字段含义时加一并在 configMigrations 中添加迁移
+const configVersion = 1
+
+type Config struct {
+	Version int `json:"version"` // 配置文件版本，见 configVersion
+
+	Game        string            `json:"game"` // 同步的游戏，见 games.go
+	NebulaURL   string            `json:"nebula_url"`
+	DeviceID    string            `json:"device_id"`
+	DeviceIDs   map[string]string `json:"device_ids,omitempty"` // 便携模式下各台电脑的设备ID，按主机名保存
+	SavePath    string            `json:"save_path"`
+	AutoSync    bool              `json:"auto_sync"`
+	AutoUpload  bool              `json:"auto_upload"`
+	AutoRestore bool              `json:"auto_restore"` // 游戏退出后自动恢复云端最新存档
+
+	PlayerProfiles []string `json:"player_profiles,omitempty"` // 监听的玩家档案（PlayerProfiles 下的文件夹名），为空时只监听 SavePath
+	GameModes      []string `json:"game_modes"`                // 同步的游戏模式（存档文件夹名后缀），为空时同步所有模式
+
+	SyncProfiles  []SyncProfile `json:"sync_profiles,omitempty"`  // 命名的同步配置
+	ActiveProfile string        `json:"active_profile,omitempty"` // 当前使用的同步配置名称
+	Mirrors       []string      `json:"mirrors,omitempty"`        // 上传时同时上传到这些同步配置的服务器
+
+	Retention RetentionPolicy `json:"retention"` // 云端与本地备份的保留策略
+
+	SoftDelete         bool `json:"soft_delete"`          // 删除的云端存档先移入回收站
+	TrashRetentionDays int  `json:"trash_retention_days"` // 回收站保留天数，超过后自动清除
+
+	CheckpointHotkey string `json:"checkpoint_hotkey"` // 创建检查点的快捷键，如 Ctrl+Shift+F9，为空禁用
+
+	AuditIntervalHours int  `json:"audit_interval_hours"` // 定期校验云端存档的间隔，0 为不自动校验
+	AuditFullDownload  bool `json:"audit_full_download"`  // 定期校验时下载并校验完整内容，否则只检查文件是否存在
+
+	Bandwidth BandwidthLimits `json:"bandwidth"` // 上传/下载限速，游戏运行时使用单独的限速
+	Network   NetworkConfig   `json:"network"`   // 代理、证书和超时
+
+	ProfileSync ProfileSyncConfig `json:"profile_sync"` // 同步 PlayerProfiles/Public 下的玩家档案文件
+}
+
+// 配置管理
+func getConfigPath() string {
+	return filepath.Join(getAppDataDir(), "config.json")
+}
+
+// 默认配置，配置文件中缺少的字段保持默认值
+func defaultConfig() *Config {
+	return &Config{
+		Version:    configVersion,
+		AutoSync:   true,
+		AutoUpload: true,
+		Game:       bg3Game.ID,
+		GameModes:  slices.Clone(bg3Game.DefaultModes), // 复制一份，解析配置时不会改写游戏定义
+		Retention:  defaultRetentionPolicy(),
+
+		SoftDelete:         true,
+		TrashRetentionDays: 30,
+
+		CheckpointHotkey: "Ctrl+Shift+F9",
+
+		AuditIntervalHours: 24 * 7,
+
+		Bandwidth: defaultBandwidthLimits(),
+		Network:   NetworkConfig{Timeouts: defaultTimeouts(), Compression: true},
+
+		ProfileSync: defaultProfileSync(),
+	}
+}
+
+// clone 通过 JSON 深拷贝配置，修改副本中的切片不会影响原配置。
+// Config 只包含可以编码为 JSON 的字段，不会出错
+func (cfg *Config) clone() *Config {
+	var c Config
+	data, _ := json.Marshal(cfg)
+	json.Unmarshal(data, &c)
+	return &c
+}
+
+// configMigrations[i] 将版本 i 的配置升级到版本 i+1，直接修改解析出的 JSON 对象
+var configMigrations = []func(raw map[string]any){
+	// 0 → 1：没有版本号的配置来自只支持博德之门3荣誉模式的版本，
+	// 明确写入当时的行为，之后修改默认值不会改变旧配置的含义
+	func(raw map[string]any) {
+		if _, ok := raw["game"]; !ok {
+			raw["game"] = bg3Game.ID
+		}
+		if _, ok := raw["game_modes"]; !ok {
+			raw["game_modes"] = []any{"HonourMode"}
+		}
+	},
+}
+
+func newerConfigError(version int) error {
+	return fmt.Errorf("配置文件版本 %d 高于当前程序支持的版本 %d，请升级程序", version, configVersion)
+}
+
+// configFileVersion 配置文件内容中的版本号，无法解析或没有版本号时为 0
+func configFileVersion(data []byte) int {
+	var header struct {
+		Version int `json:"version"`
+	}
+	json.Unmarshal(data, &header)
+	return header.Version
+}
+
+// migrateConfig 将配置升级到当前版本
+func migrateConfig(raw map[string]any) error {
+	version := 0
+	if v, ok := raw["version"].(float64); ok {
+		version = int(v)
+	}
+	if version > configVersion {
+		return newerConfigError(version)
+	}
+
+	for ; version < configVersion; version++ {
+		configMigrations[version](raw)
+		log.Printf("配置已从版本 %d 升级到 %d\n", version, version+1)
+	}
+	raw["version"] = configVersion
+	return nil
+}
+
+// loadConfig 读取配置文件。文件不存在时返回默认配置；
+// 无法解析时将原文件另存一份以免被之后的保存覆盖，并返回默认配置和错误
+func loadConfig() (*Config, error) {
+	path := getConfigPath()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return defaultConfig(), nil
+	}
+	if err != nil {
+		return defaultConfig(), fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	config, err := parseConfig(data)
+	if err != nil {
+		broken := path + ".broken-" + time.Now().Format(localBackupTimeFormat)
+		if copyErr := os.WriteFile(broken, data, 0644); copyErr == nil {
+			err = fmt.Errorf("%w（原文件已另存为 %s）", err, broken)
+		}
+		return defaultConfig(), err
+	}
+	return config, nil
+}
+
+// parseConfig 解析配置文件内容并升级到当前版本
+func parseConfig(data []byte) (*Config, error) {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %w", err)
+	}
+	if err := migrateConfig(raw); err != nil {
+		return nil, err
+	}
+
+	migrated, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	config := defaultConfig()
+	if err := json.Unmarshal(migrated, config); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %w", err)
+	}
+	return config, nil
+}
+
+// saveConfig 先写入临时文件再替换，替换前将原配置备份为 config.json.bak。
+// 原文件由更新版本的程序写入时拒绝覆盖，以免丢失旧程序不认识的设置
+func saveConfig(config *Config) error {
+	path := getConfigPath()
+	old, err := os.ReadFile(path)
+	if err == nil {
+		if version := configFileVersion(old); version > configVersion {
+			return fmt.Errorf("未保存配置: %w", newerConfigError(version))
+		}
+	}
+
+	config.Version = configVersion
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if old != nil {
+		if err := os.WriteFile(path+".bak", old, 0644); err != nil {
+			return fmt.Errorf("备份配置文件失败: %w", err)
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "config-*.tmp")
+	if err != nil {
+		return fmt.Errorf("保存配置失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("保存配置失败: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("保存配置失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("保存配置失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("保存配置失败: %w", err)
+	}
+	return nil
+}
+
+// Validate 检查配置是否有效，返回所有问题
+func (cfg *Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if cfg.NebulaURL != "" {
+		if err := validateServerURL(cfg.NebulaURL); err != nil {
+			invalid("服务器地址无效: %v", err)
+		}
+	}
+	// 存档目录可能还没有被游戏创建，是否存在由修改存档路径时的 validateWritableDir 检查
+	if cfg.SavePath == "" {
+		invalid("存档路径未设置")
+	}
+	if gameByID(cfg.Game).ID != cfg.Game {
+		invalid("未知的游戏: %s", cfg.Game)
+	}
+
+	if cfg.TrashRetentionDays < 0 {
+		invalid("回收站保留天数不能为负数")
+	}
+	if cfg.AuditIntervalHours < 0 {
+		invalid("校验间隔不能为负数")
+	}
+	if cfg.Retention.KeepLast < 1 {
+		invalid("每个战役至少保留 1 个版本")
+	}
+	if cfg.Retention.HourlyHours < 0 || cfg.Retention.DailyDays < 0 {
+		invalid("保留策略的小时数和天数不能为负数")
+	}
+
+	b := cfg.Bandwidth
+	if b.UploadKBps < 0 || b.DownloadKBps < 0 || b.GameUploadKBps < 0 || b.GameDownloadKBps < 0 {
+		invalid("限速不能为负数")
+	}
+	t := cfg.Network.Timeouts
+	if t.ConnectSeconds < 0 || t.RequestSeconds < 0 || t.TransferMinutes < 0 {
+		invalid("超时不能为负数")
+	}
+	if cfg.Network.ProxyURL != "" {
+		if _, err := parseProxyURL(cfg.Network.ProxyURL); err != nil {
+			invalid("%v", err)
+		}
+	}
+
+	if cfg.CheckpointHotkey != "" {
+		if _, err := parseHotkey(cfg.CheckpointHotkey); err != nil {
+			invalid("检查点快捷键无效: %v", err)
+		}
+	}
+
+	for _, p := range cfg.SyncProfiles {
+		if p.NebulaURL == "" {
+			continue
+		}
+		if err := validateServerURL(p.NebulaURL); err != nil {
+			invalid("同步配置 %s 的服务器地址无效: %v", p.Name, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateServerURL 服务器地址必须是带主机名的 http 或 https 地址
+func validateServerURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("只支持 http 和 https")
+	}
+	if u.Host == "" {
+		return fmt.Errorf("缺少主机名")
+	}
+	return nil
+}
+
+// validateWritableDir 检查目录存在且可写，用临时文件测试写入后立即删除
+func validateWritableDir(dir string) error {
+	if dir == "" {
+		return fmt.Errorf("未设置")
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s 不是目录", dir)
+	}
+
+	f, err := os.CreateTemp(dir, ".bg3sync-write-test-*")
+	if err != nil {
+		return fmt.Errorf("目录不可写: %w", err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseConfigKeepsGameDefaults(t *testing.T) {
+	want := slices.Clone(bg3Game.DefaultModes)
+
+	cfg, err := parseConfig([]byte(`{"version": 1, "game_modes": ["Classic"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(cfg.GameModes, []string{"Classic"}) {
+		t.Errorf("GameModes = %v, want [Classic]", cfg.GameModes)
+	}
+	if !slices.Equal(bg3Game.DefaultModes, want) {
+		t.Errorf("解析配置改写了默认模式: %v, want %v", bg3Game.DefaultModes, want)
+	}
+	if got := defaultConfig().GameModes; !slices.Equal(got, want) {
+		t.Errorf("defaultConfig().GameModes = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+// attachParentConsole 其他系统的程序总是可以输出到启动它的终端，不需要处理
+func attachParentConsole() {}
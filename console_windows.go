@@ -0,0 +1,33 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32          = windows.NewLazySystemDLL("kernel32.dll")
+	procAttachConsole = kernel32.NewProc("AttachConsole")
+)
+
+const attachParentProcess = ^uintptr(0) // ATTACH_PARENT_PROCESS
+
+// attachParentConsole 以 GUI 程序编译时没有控制台，从命令行运行 config show 或 -h 时
+// 附加到启动它的控制台，让输出显示在命令行中。双击启动时没有父控制台，什么也不做
+func attachParentConsole() {
+	if r, _, _ := procAttachConsole.Call(attachParentProcess); r == 0 {
+		return
+	}
+	out, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	os.Stdout = out
+	os.Stderr = out
+	windows.SetStdHandle(windows.STD_OUTPUT_HANDLE, windows.Handle(out.Fd()))
+	windows.SetStdHandle(windows.STD_ERROR_HANDLE, windows.Handle(out.Fd()))
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrTooLarge 上传的文件超过服务端（或反向代理）允许的大小
+	ErrTooLarge = errors.New("文件太大")
+	// ErrUnauthorized 服务端拒绝访问
+	ErrUnauthorized = errors.New("未授权")
+	// ErrNotFound 请求的存档或接口不存在
+	ErrNotFound = errors.New("未找到")
+	// ErrServer 服务端内部错误 (5xx)
+	ErrServer = errors.New("服务器错误")
+	// ErrNetwork 网络错误（连接失败、超时等）
+	ErrNetwork = errors.New("网络错误")
+	// ErrPinned 存档已固定，不允许删除
+	ErrPinned = errors.New("存档已固定，请先取消固定")
+	// ErrTrashUnsupported 服务端不支持回收站
+	ErrTrashUnsupported = errors.New("服务端不支持回收站")
+)
+
+// APIError 服务端返回的非成功响应，可用 errors.Is 与上面的错误比较
+type APIError struct {
+	Op         string // 操作，如 "上传"
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration // 服务端要求的重试等待时间（Retry-After）
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s失败 (状态码: %d)", e.Op, e.StatusCode)
+	}
+	return fmt.Sprintf("%s失败 (状态码: %d): %s", e.Op, e.StatusCode, e.Message)
+}
+
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusRequestEntityTooLarge:
+		return ErrTooLarge
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusLocked:
+		return ErrPinned
+	case e.StatusCode >= 500:
+		return ErrServer
+	}
+	return nil
+}
+
+// NetworkError 请求没有得到服务端响应
+type NetworkError struct {
+	Op  string
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("%s失败: 网络错误: %v", e.Op, e.Err)
+}
+
+func (e *NetworkError) Unwrap() []error {
+	return []error{ErrNetwork, e.Err}
+}
+
+// statusCode 返回错误对应的 HTTP 状态码，不是 APIError 时返回 0
+func statusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// parseRetryAfter 解析 Retry-After 头（秒数或 HTTP 日期）
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
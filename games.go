@@ -0,0 +1,200 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// GameDefinition 描述一个 Larian 游戏的存档位置、进程名和存档文件约定
+type GameDefinition struct {
+	ID   string // 配置中使用的标识
+	Name string // 显示名称
+
+	// 游戏数据目录（PlayerProfiles 所在目录），按操作系统区分。
+	// 第一段为基准目录：~ 为用户主目录，%LOCALAPPDATA% 为 Windows 本地应用数据目录
+	DataDirs map[string][]string
+
+	ProcessNames map[string][]string // 按操作系统区分的游戏进程名
+
+	SaveExt       string // 存档文件扩展名
+	ThumbnailExts []string
+
+	// ValidSave 检查存档文件是否是有效的 LSPK 包，不同版本的签名位置不同
+	ValidSave func(r io.ReaderAt, size int64) bool
+
+	Modes        []string // 已知的游戏模式，用于筛选
+	DefaultModes []string // 默认同步的游戏模式，为空时同步所有存档
+
+	// ModeOf 从存档文件夹名解析游戏模式，为 nil 表示文件夹名中不包含模式
+	ModeOf func(folderName string) string
+}
+
+var bg3Game = &GameDefinition{
+	ID:   "bg3",
+	Name: "博德之门3",
+	DataDirs: map[string][]string{
+		"windows": {"%LOCALAPPDATA%", "Larian Studios", "Baldur's Gate 3"},
+		"darwin":  {"~", "Library", "Application Support", "Larian Studios", "Baldur's Gate 3"},
+		"linux":   {"~", ".local", "share", "Larian Studios", "Baldur's Gate 3"},
+	},
+	ProcessNames: map[string][]string{
+		"windows": {"bg3.exe", "bg3_dx11.exe"},
+		"darwin":  {"Baldur's Gate 3"},
+		// Linux 上通过 Proton 运行 Windows 版
+		"linux": {"bg3", "bg3.bin", "bg3.exe", "bg3_dx11.exe"},
+	},
+	SaveExt:       ".lsv",
+	ThumbnailExts: []string{".WebP"},
+	ValidSave:     lspkAtStart,
+	Modes:         []string{"HonourMode"},
+	DefaultModes:  []string{"HonourMode"},
+	ModeOf:        gameModeOf,
+}
+
+// 原罪2 的难度（包括战术大师）保存在存档内，文件夹名只有存档名，因此不按模式筛选
+var dos2Game = &GameDefinition{
+	ID:   "dos2",
+	Name: "神界：原罪2 终极版",
+	DataDirs: map[string][]string{
+		"windows": {"~", "Documents", "Larian Studios", "Divinity Original Sin 2 Definitive Edition"},
+		"darwin":  {"~", "Documents", "Larian Studios", "Divinity Original Sin 2 Definitive Edition"},
+		// Linux 上通过 Proton 运行，数据在 Steam 的 Wine 前缀中
+		"linux": {"~", ".steam", "steam", "steamapps", "compatdata", "435150", "pfx", "drive_c",
+			"users", "steamuser", "Documents", "Larian Studios", "Divinity Original Sin 2 Definitive Edition"},
+	},
+	ProcessNames: map[string][]string{
+		"windows": {"EoCApp.exe"},
+		"darwin":  {"EoCApp"},
+		"linux":   {"EoCApp.exe"},
+	},
+	SaveExt:       ".lsv",
+	ThumbnailExts: []string{".png"},
+	// 终极版使用 LSPK v13，签名在文件末尾；原版使用的 v10 签名在文件开头
+	ValidSave: func(r io.ReaderAt, size int64) bool {
+		return lspkAtEnd(r, size) || lspkAtStart(r, size)
+	},
+}
+
+// games 支持的游戏，第一个为默认
+var games = []*GameDefinition{bg3Game, dos2Game}
+
+// gameByID 按标识查找游戏，找不到时返回默认游戏
+func gameByID(id string) *GameDefinition {
+	for _, g := range games {
+		if g.ID == id {
+			return g
+		}
+	}
+	return games[0]
+}
+
+// gameByName 按显示名称查找游戏
+func gameByName(name string) *GameDefinition {
+	for _, g := range games {
+		if g.Name == name {
+			return g
+		}
+	}
+	return games[0]
+}
+
+// gameNames 所有游戏的显示名称
+func gameNames() []string {
+	var names []string
+	for _, g := range games {
+		names = append(names, g.Name)
+	}
+	return names
+}
+
+// DataDir 当前系统上的游戏数据目录
+func (g *GameDefinition) DataDir() string {
+	parts, ok := g.DataDirs[runtime.GOOS]
+	if !ok {
+		parts = g.DataDirs["linux"]
+	}
+
+	base := parts[0]
+	switch base {
+	case "~":
+		base, _ = os.UserHomeDir()
+	case "%LOCALAPPDATA%":
+		base = os.Getenv("LOCALAPPDATA")
+	}
+	return filepath.Join(append([]string{base}, parts[1:]...)...)
+}
+
+// DefaultSavePath 默认玩家档案的存档目录。博德之门3 使用 Public；
+// 原罪2 没有 Public，玩家档案以玩家名命名，使用找到的第一个
+func (g *GameDefinition) DefaultSavePath() string {
+	root := filepath.Join(g.DataDir(), "PlayerProfiles")
+	profile := defaultPlayerProfile
+	if _, err := os.Stat(filepath.Join(root, profile)); err != nil {
+		if entries, err := os.ReadDir(root); err == nil {
+			for _, entry := range entries {
+				if entry.IsDir() {
+					profile = entry.Name()
+					break
+				}
+			}
+		}
+	}
+	return filepath.Join(root, profile, "Savegames", "Story")
+}
+
+// Processes 当前系统上的游戏进程名
+func (g *GameDefinition) Processes() []string {
+	if names, ok := g.ProcessNames[runtime.GOOS]; ok {
+		return names
+	}
+	return g.ProcessNames["linux"]
+}
+
+// MatchesMode 存档文件夹是否属于要同步的游戏模式，未设置模式或游戏不区分模式时同步所有存档
+func (g *GameDefinition) MatchesMode(folderName string, modes []string) bool {
+	if len(modes) == 0 || g.ModeOf == nil {
+		return true
+	}
+	return slices.Contains(modes, g.ModeOf(folderName))
+}
+
+// isSaveFileExt 是否是任一游戏的存档文件或截图
+func isSaveFileExt(ext string) bool {
+	return isSaveExt(ext) || isThumbnailExt(ext)
+}
+
+func isSaveExt(ext string) bool {
+	for _, g := range games {
+		if strings.EqualFold(ext, g.SaveExt) {
+			return true
+		}
+	}
+	return false
+}
+
+func isThumbnailExt(ext string) bool {
+	for _, g := range games {
+		if slices.Contains(g.ThumbnailExts, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// game 当前同步的游戏
+func (c *Client) game() *GameDefinition {
+	return gameByID(c.config.Load().Game)
+}
+
+// isOtherGameSave 云端存档是否属于其他游戏，旧版本上传的存档没有记录游戏，视为博德之门3
+func (c *Client) isOtherGameSave(save *SaveGame) bool {
+	id := save.Game
+	if id == "" {
+		id = bg3Game.ID
+	}
+	return id != c.game().ID
+}
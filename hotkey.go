@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+// errGlobalHotkeyUnsupported 当前系统不支持全局快捷键
+var errGlobalHotkeyUnsupported = errors.New("当前系统不支持全局快捷键")
+
+// Hotkey 快捷键，如 Ctrl+Shift+F9
+type Hotkey struct {
+	Ctrl  bool
+	Alt   bool
+	Shift bool
+	Super bool
+	Key   string // F1-F24、A-Z 或 0-9
+}
+
+// parseHotkey 解析 "Ctrl+Shift+F9" 形式的快捷键
+func parseHotkey(text string) (*Hotkey, error) {
+	hk := &Hotkey{}
+	for _, part := range strings.Split(text, "+") {
+		part = strings.TrimSpace(part)
+		switch strings.ToLower(part) {
+		case "ctrl", "control":
+			hk.Ctrl = true
+		case "alt":
+			hk.Alt = true
+		case "shift":
+			hk.Shift = true
+		case "win", "super", "cmd":
+			hk.Super = true
+		default:
+			if hk.Key != "" {
+				return nil, fmt.Errorf("快捷键只能包含一个按键: %s", text)
+			}
+			hk.Key = strings.ToUpper(part)
+		}
+	}
+
+	if !validHotkeyKey(hk.Key) {
+		return nil, fmt.Errorf("不支持的快捷键: %s（按键须为 F1-F24、A-Z 或 0-9）", text)
+	}
+	if !hk.Ctrl && !hk.Alt && !hk.Shift && !hk.Super && !strings.HasPrefix(hk.Key, "F") {
+		return nil, fmt.Errorf("字母和数字快捷键需要至少一个修饰键: %s", text)
+	}
+
+	return hk, nil
+}
+
+func validHotkeyKey(key string) bool {
+	if len(key) == 1 {
+		return (key[0] >= 'A' && key[0] <= 'Z') || (key[0] >= '0' && key[0] <= '9')
+	}
+	var n int
+	if _, err := fmt.Sscanf(key, "F%d", &n); err == nil && fmt.Sprintf("F%d", n) == key {
+		return n >= 1 && n <= 24
+	}
+	return false
+}
+
+func (hk *Hotkey) String() string {
+	var parts []string
+	if hk.Ctrl {
+		parts = append(parts, "Ctrl")
+	}
+	if hk.Alt {
+		parts = append(parts, "Alt")
+	}
+	if hk.Shift {
+		parts = append(parts, "Shift")
+	}
+	if hk.Super {
+		parts = append(parts, "Win")
+	}
+	return strings.Join(append(parts, hk.Key), "+")
+}
+
+// shortcut 转换为窗口内快捷键（窗口获得焦点时生效）
+func (hk *Hotkey) shortcut() *desktop.CustomShortcut {
+	var mod fyne.KeyModifier
+	if hk.Ctrl {
+		mod |= fyne.KeyModifierControl
+	}
+	if hk.Alt {
+		mod |= fyne.KeyModifierAlt
+	}
+	if hk.Shift {
+		mod |= fyne.KeyModifierShift
+	}
+	if hk.Super {
+		mod |= fyne.KeyModifierSuper
+	}
+	return &desktop.CustomShortcut{KeyName: fyne.KeyName(hk.Key), Modifier: mod}
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+// registerGlobalHotkey 非 Windows 系统不支持全局快捷键，仅使用窗口内快捷键和托盘菜单
+func registerGlobalHotkey(hk *Hotkey, fn func()) (func(), error) {
+	return nil, errGlobalHotkeyUnsupported
+}
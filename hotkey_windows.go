@@ -0,0 +1,114 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	user32                 = windows.NewLazySystemDLL("user32.dll")
+	procRegisterHotKey     = user32.NewProc("RegisterHotKey")
+	procUnregisterHotKey   = user32.NewProc("UnregisterHotKey")
+	procGetMessageW        = user32.NewProc("GetMessageW")
+	procPostThreadMessageW = user32.NewProc("PostThreadMessageW")
+)
+
+const (
+	modAlt      = 0x0001
+	modControl  = 0x0002
+	modShift    = 0x0004
+	modWin      = 0x0008
+	modNoRepeat = 0x4000
+
+	wmHotkey = 0x0312
+	wmQuit   = 0x0012
+
+	hotkeyID = 1
+)
+
+// winMsg 对应 Win32 MSG 结构
+type winMsg struct {
+	HWnd    uintptr
+	Message uint32
+	WParam  uintptr
+	LParam  uintptr
+	Time    uint32
+	Pt      struct{ X, Y int32 }
+}
+
+// registerGlobalHotkey 注册全局快捷键 (Windows)，返回用于注销的函数
+func registerGlobalHotkey(hk *Hotkey, fn func()) (func(), error) {
+	var mods uintptr = modNoRepeat
+	if hk.Ctrl {
+		mods |= modControl
+	}
+	if hk.Alt {
+		mods |= modAlt
+	}
+	if hk.Shift {
+		mods |= modShift
+	}
+	if hk.Super {
+		mods |= modWin
+	}
+
+	vk := virtualKeyCode(hk.Key)
+	errCh := make(chan error, 1)
+	threadCh := make(chan uint32, 1)
+	done := make(chan struct{})
+
+	// 热键消息发送到注册线程的消息队列，必须在同一个线程上接收
+	go func() {
+		defer close(done)
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+
+		r, _, err := procRegisterHotKey.Call(0, hotkeyID, mods, vk)
+		if r == 0 {
+			errCh <- fmt.Errorf("注册全局快捷键 %s 失败: %w", hk, err)
+			return
+		}
+		defer procUnregisterHotKey.Call(0, hotkeyID)
+
+		threadCh <- windows.GetCurrentThreadId()
+		errCh <- nil
+
+		var m winMsg
+		for {
+			r, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+			if int32(r) <= 0 {
+				return // WM_QUIT 或出错
+			}
+			if m.Message == wmHotkey && m.WParam == hotkeyID {
+				go fn()
+			}
+		}
+	}()
+
+	if err := <-errCh; err != nil {
+		return nil, err
+	}
+	threadID := <-threadCh
+
+	// 等待线程注销快捷键后再返回，之后可以立即重新注册同一个快捷键
+	return func() {
+		procPostThreadMessageW.Call(uintptr(threadID), wmQuit, 0, 0)
+		<-done
+	}, nil
+}
+
+// virtualKeyCode 按键名转换为 Windows 虚拟键码
+func virtualKeyCode(key string) uintptr {
+	if len(key) == 1 {
+		return uintptr(key[0]) // A-Z、0-9 的虚拟键码与 ASCII 相同
+	}
+	n, _ := strconv.Atoi(key[1:])
+	return uintptr(0x70 + n - 1) // VK_F1 = 0x70
+}
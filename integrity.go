@@ -0,0 +1,206 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// .lsv 存档文件（LSPK 包）的签名
+var lsvMagic = []byte("LSPK")
+
+// lspkAtStart LSPK v10 和 v15 及以上（博德之门3）的签名在文件开头
+func lspkAtStart(r io.ReaderAt, size int64) bool {
+	return hasMagicAt(r, 0)
+}
+
+// lspkAtEnd LSPK v13 的文件头在文件末尾，最后 8 字节是文件头大小和签名
+func lspkAtEnd(r io.ReaderAt, size int64) bool {
+	return size >= 8 && hasMagicAt(r, size-int64(len(lsvMagic)))
+}
+
+func hasMagicAt(r io.ReaderAt, offset int64) bool {
+	magic := make([]byte, len(lsvMagic))
+	if _, err := r.ReadAt(magic, offset); err != nil {
+		return false
+	}
+	return bytes.Equal(magic, lsvMagic)
+}
+
+// verifyArchive 校验下载的存档：大小、SHA-256 和压缩包内每个文件的 CRC
+func verifyArchive(save *SaveGame, data []byte) error {
+	if save.FileSize > 0 && int64(len(data)) != save.FileSize {
+		return fmt.Errorf("文件大小不匹配: 期望 %d 字节，实际 %d 字节", save.FileSize, len(data))
+	}
+
+	if expected, ok := sha256Of(save.FileHash); ok {
+		sum := sha256.Sum256(data)
+		if actual := hex.EncodeToString(sum[:]); actual != expected {
+			return fmt.Errorf("SHA-256 不匹配: 期望 %s，实际 %s", expected, actual)
+		}
+	} else if save.FileHash != "" {
+		log.Printf("⚠️  无法识别的哈希格式，跳过哈希校验: %s\n", save.FileHash)
+	}
+
+	return verifyZip(data)
+}
+
+// sha256Of 解析服务端返回的哈希（支持 "sha256:" 前缀），不是 SHA-256 时返回 false
+func sha256Of(fileHash string) (string, bool) {
+	hash := strings.ToLower(strings.TrimPrefix(fileHash, "sha256:"))
+	if len(hash) != sha256.Size*2 {
+		return "", false
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return "", false
+	}
+	return hash, true
+}
+
+// verifyZip 完整读取压缩包内的每个文件，CRC 不匹配时 archive/zip 会返回错误
+func verifyZip(data []byte) error {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("压缩包损坏: %w", err)
+	}
+
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("压缩包文件 %s 损坏: %w", file.Name, err)
+		}
+		_, err = io.Copy(io.Discard, rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("压缩包文件 %s 校验失败: %w", file.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateSaveFolder 检查解压后的存档文件夹包含所属游戏的有效存档文件
+func validateSaveFolder(folderPath string, game *GameDefinition) error {
+	info, err := readSaveInfo(folderPath)
+	if err != nil {
+		return err
+	}
+
+	lsvPath := filepath.Join(folderPath, info.SaveFile)
+	f, err := os.Open(lsvPath)
+	if err != nil {
+		return fmt.Errorf("打开存档文件失败: %w", err)
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("读取存档文件失败: %w", err)
+	}
+	if game.ValidSave != nil && !game.ValidSave(f, stat.Size()) {
+		return fmt.Errorf("存档文件不是有效的%s存档: %s", game.Name, filepath.Base(lsvPath))
+	}
+
+	return nil
+}
+
+// downloadVerified 下载并校验存档，校验失败时重新下载一次；progress 可为 nil
+func (c *Client) downloadVerified(ctx context.Context, save *SaveGame, progress ProgressFunc) ([]byte, error) {
+	var lastErr error
+	for attempt := 1; attempt <= 2; attempt++ {
+		data, err := c.api.Load().DownloadSave(ctx, save.ID, progress)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := verifyArchive(save, data); err != nil {
+			log.Printf("⚠️  存档校验失败 (第 %d 次): %s: %v\n", attempt, save.FileName, err)
+			lastErr = err
+			continue
+		}
+
+		return data, nil
+	}
+
+	return nil, fmt.Errorf("存档校验失败: %w", lastErr)
+}
+
+// 恢复时解压的临时目录，放在数据目录中避免被存档目录的监听当作新存档
+func getRestoringDir() string {
+	return filepath.Join(getAppDataDir(), "restoring")
+}
+
+// restoreArchive 将存档解压到临时文件夹并校验，通过后备份本地存档再替换；
+// 替换时先把旧存档移到一旁，新存档就位后才删除，失败时移回原处
+func (c *Client) restoreArchive(save *SaveGame, data []byte) (string, error) {
+	// 解压到本地（去掉 .zip 后缀作为文件夹名）
+	folderName := strings.TrimSuffix(save.FileName, ".zip")
+	saveFolderPath := filepath.Join(c.saveDirFor(save), folderName)
+	tempPath := filepath.Join(getRestoringDir(), folderName)
+
+	os.RemoveAll(tempPath)
+	defer os.RemoveAll(tempPath)
+	if err := unzipToFolder(data, tempPath); err != nil {
+		return "", fmt.Errorf("解压失败: %w", err)
+	}
+
+	if err := validateSaveFolder(tempPath, gameByID(save.Game)); err != nil {
+		return "", fmt.Errorf("存档校验失败: %w", err)
+	}
+
+	// 覆盖前先备份本地存档
+	backupPath, err := backupLocalFolder(saveFolderPath)
+	if err != nil {
+		return "", fmt.Errorf("备份本地存档失败: %w", err)
+	}
+	failed := func(format string, err error) error {
+		if backupPath != "" {
+			return fmt.Errorf(format+"（原存档已备份到 %s）", err, backupPath)
+		}
+		return fmt.Errorf(format, err)
+	}
+
+	oldPath := ""
+	if _, err := os.Stat(saveFolderPath); err == nil {
+		oldPath = saveFolderPath + ".old"
+		os.RemoveAll(oldPath)
+		if err := os.Rename(saveFolderPath, oldPath); err != nil {
+			return "", failed("移走旧存档失败: %w", err)
+		}
+	}
+
+	// 数据目录和存档目录不在同一个磁盘时无法直接移动，改为直接解压到存档目录
+	err = os.Rename(tempPath, saveFolderPath)
+	if err != nil {
+		os.RemoveAll(saveFolderPath)
+		err = unzipToFolder(data, saveFolderPath)
+	}
+	if err != nil {
+		os.RemoveAll(saveFolderPath)
+		if oldPath != "" {
+			if rollbackErr := os.Rename(oldPath, saveFolderPath); rollbackErr != nil {
+				log.Printf("⚠️  移回旧存档失败: %v\n", rollbackErr)
+			}
+		}
+		return "", failed("替换存档失败: %w", err)
+	}
+
+	if oldPath != "" {
+		if err := os.RemoveAll(oldPath); err != nil {
+			log.Printf("删除旧存档失败 %s: %v\n", oldPath, err)
+		}
+	}
+	return folderName, nil
+}
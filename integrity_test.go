@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateSaveFolder(t *testing.T) {
+	// v13（原罪2 终极版）：文件头在末尾，最后是文件头大小和签名
+	v13 := append(bytes.Repeat([]byte{0}, 64), 0x28, 0, 0, 0, 'L', 'S', 'P', 'K')
+	// v18（博德之门3）：签名在开头
+	v18 := append([]byte("LSPK"), bytes.Repeat([]byte{0}, 64)...)
+	invalid := bytes.Repeat([]byte{0}, 64)
+
+	tests := []struct {
+		name    string
+		game    *GameDefinition
+		data    []byte
+		wantErr bool
+	}{
+		{"博德之门3", bg3Game, v18, false},
+		{"博德之门3 签名在末尾", bg3Game, v13, true},
+		{"原罪2 终极版", dos2Game, v13, false},
+		{"原罪2 签名在开头", dos2Game, v18, false},
+		{"原罪2 无效文件", dos2Game, invalid, true},
+		{"文件太短", dos2Game, []byte("LSP"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "Save")
+			if err := os.Mkdir(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "Save.lsv"), tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := validateSaveFolder(dir, tt.game)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSaveFolder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
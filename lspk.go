@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// 读取存档文件（LSPK 包）中的单个文件。只支持存档使用的格式：
+// v13（原罪2 终极版，文件头在末尾）和 v15/v16/v18（博德之门3，文件头在开头），
+// 压缩方式支持 zlib 和 LZ4，zstd 压缩的包无法读取
+
+// LSPK 中的压缩方式（Flags 低 4 位）
+const (
+	lspkCompressNone = 0
+	lspkCompressZlib = 1
+	lspkCompressLZ4  = 2
+)
+
+var errLSPKUnsupported = errors.New("不支持的存档包格式")
+
+// lspkEntry 包中的一个文件
+type lspkEntry struct {
+	Name             string
+	Offset           int64
+	SizeOnDisk       int64
+	UncompressedSize int64
+	Flags            uint32
+}
+
+// readLSPKFile 从存档包中读取指定名称的文件（不区分大小写）
+func readLSPKFile(r io.ReaderAt, size int64, name string) ([]byte, error) {
+	entries, err := readLSPKEntries(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if !strings.EqualFold(entry.Name, name) {
+			continue
+		}
+		data := make([]byte, entry.SizeOnDisk)
+		if _, err := r.ReadAt(data, entry.Offset); err != nil {
+			return nil, fmt.Errorf("读取 %s 失败: %w", name, err)
+		}
+		if entry.UncompressedSize == 0 {
+			return data, nil
+		}
+		return decompressLS(data, int(entry.UncompressedSize), entry.Flags, false)
+	}
+	return nil, fmt.Errorf("存档包中没有 %s", name)
+}
+
+// readLSPKEntries 读取包的文件列表
+func readLSPKEntries(r io.ReaderAt, size int64) ([]lspkEntry, error) {
+	if lspkAtStart(r, size) {
+		return readLSPKEntriesV15(r)
+	}
+	if lspkAtEnd(r, size) {
+		return readLSPKEntriesV13(r, size)
+	}
+	return nil, errLSPKUnsupported
+}
+
+// readLSPKEntriesV13 v13 的文件头位于末尾：文件头大小和签名之前
+func readLSPKEntriesV13(r io.ReaderAt, size int64) ([]lspkEntry, error) {
+	var footer [8]byte
+	if _, err := r.ReadAt(footer[:], size-8); err != nil {
+		return nil, err
+	}
+	headerSize := int64(binary.LittleEndian.Uint32(footer[:4]))
+	if headerSize < 12 || headerSize > size {
+		return nil, errLSPKUnsupported
+	}
+
+	var header [12]byte // Version, FileListOffset, FileListSize
+	if _, err := r.ReadAt(header[:], size-headerSize); err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint32(header[0:]) != 13 {
+		return nil, errLSPKUnsupported
+	}
+	listOffset := int64(binary.LittleEndian.Uint32(header[4:]))
+	listSize := int64(binary.LittleEndian.Uint32(header[8:]))
+	if listSize < 4 || listOffset+listSize > size {
+		return nil, errLSPKUnsupported
+	}
+
+	list := make([]byte, listSize)
+	if _, err := r.ReadAt(list, listOffset); err != nil {
+		return nil, err
+	}
+	count := int(binary.LittleEndian.Uint32(list))
+	const entrySize = 280 // Name[256], Offset, SizeOnDisk, UncompressedSize, ArchivePart, Flags, Crc
+	raw, err := lz4DecompressBlock(list[4:], count*entrySize)
+	if err != nil {
+		return nil, fmt.Errorf("解压文件列表失败: %w", err)
+	}
+
+	entries := make([]lspkEntry, count)
+	for i := range entries {
+		e := raw[i*entrySize:]
+		entries[i] = lspkEntry{
+			Name:             cString(e[:256]),
+			Offset:           int64(binary.LittleEndian.Uint32(e[256:])),
+			SizeOnDisk:       int64(binary.LittleEndian.Uint32(e[260:])),
+			UncompressedSize: int64(binary.LittleEndian.Uint32(e[264:])),
+			Flags:            binary.LittleEndian.Uint32(e[272:]),
+		}
+	}
+	return entries, nil
+}
+
+// readLSPKEntriesV15 v15 及以上的文件头紧跟在签名之后
+func readLSPKEntriesV15(r io.ReaderAt) ([]lspkEntry, error) {
+	var header [16]byte // Magic, Version, FileListOffset(8)
+	if _, err := r.ReadAt(header[:], 0); err != nil {
+		return nil, err
+	}
+	version := binary.LittleEndian.Uint32(header[4:])
+	listOffset := int64(binary.LittleEndian.Uint64(header[8:]))
+
+	var listHeader [8]byte // NumFiles, CompressedSize
+	if _, err := r.ReadAt(listHeader[:], listOffset); err != nil {
+		return nil, err
+	}
+	count := int(binary.LittleEndian.Uint32(listHeader[0:]))
+	compressedSize := int(binary.LittleEndian.Uint32(listHeader[4:]))
+	if count < 0 || compressedSize < 0 || count > 1<<20 {
+		return nil, errLSPKUnsupported
+	}
+
+	var entrySize int
+	switch version {
+	case 15, 16:
+		entrySize = 296 // Name[256], Offset(8), SizeOnDisk(8), UncompressedSize(8), ArchivePart, Flags, Crc, Unknown
+	case 18:
+		entrySize = 272 // Name[256], Offset(4+2), ArchivePart(1), Flags(1), SizeOnDisk, UncompressedSize
+	default:
+		return nil, fmt.Errorf("%w: v%d", errLSPKUnsupported, version)
+	}
+
+	compressed := make([]byte, compressedSize)
+	if _, err := r.ReadAt(compressed, listOffset+8); err != nil {
+		return nil, err
+	}
+	raw, err := lz4DecompressBlock(compressed, count*entrySize)
+	if err != nil {
+		return nil, fmt.Errorf("解压文件列表失败: %w", err)
+	}
+
+	entries := make([]lspkEntry, count)
+	for i := range entries {
+		e := raw[i*entrySize:]
+		entry := lspkEntry{Name: cString(e[:256])}
+		if version == 18 {
+			entry.Offset = int64(binary.LittleEndian.Uint32(e[256:])) | int64(binary.LittleEndian.Uint16(e[260:]))<<32
+			entry.Flags = uint32(e[263])
+			entry.SizeOnDisk = int64(binary.LittleEndian.Uint32(e[264:]))
+			entry.UncompressedSize = int64(binary.LittleEndian.Uint32(e[268:]))
+		} else {
+			entry.Offset = int64(binary.LittleEndian.Uint64(e[256:]))
+			entry.SizeOnDisk = int64(binary.LittleEndian.Uint64(e[264:]))
+			entry.UncompressedSize = int64(binary.LittleEndian.Uint64(e[272:]))
+			entry.Flags = binary.LittleEndian.Uint32(e[284:])
+		}
+		entries[i] = entry
+	}
+	return entries, nil
+}
+
+// decompressLS 按 Larian 格式的压缩标志解压；chunked 表示 LZ4 帧格式（LSF 的节点和属性数据）
+func decompressLS(data []byte, size int, flags uint32, chunked bool) ([]byte, error) {
+	switch flags & 0x0F {
+	case lspkCompressNone:
+		return data, nil
+	case lspkCompressZlib:
+		zr, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		out := make([]byte, size)
+		if _, err := io.ReadFull(zr, out); err != nil {
+			return nil, err
+		}
+		return out, nil
+	case lspkCompressLZ4:
+		if chunked {
+			return lz4DecompressFrame(data)
+		}
+		return lz4DecompressBlock(data, size)
+	default:
+		return nil, fmt.Errorf("不支持的压缩方式: %d", flags&0x0F)
+	}
+}
+
+var errLZ4Corrupt = errors.New("LZ4 数据损坏")
+
+// lz4DecompressBlock 解压 LZ4 块，size 为解压后的大小
+func lz4DecompressBlock(src []byte, size int) ([]byte, error) {
+	dst, err := lz4AppendBlock(make([]byte, 0, size), src)
+	if err != nil {
+		return nil, err
+	}
+	if len(dst) != size {
+		return nil, fmt.Errorf("%w: 解压后大小 %d，期望 %d", errLZ4Corrupt, len(dst), size)
+	}
+	return dst, nil
+}
+
+// lz4AppendBlock 将 LZ4 块解压后追加到 dst，匹配可以引用 dst 中已有的数据（帧中相互关联的块）
+func lz4AppendBlock(dst, src []byte) ([]byte, error) {
+	readLength := func(n int, i *int) (int, error) {
+		if n != 15 {
+			return n, nil
+		}
+		for {
+			if *i >= len(src) {
+				return 0, errLZ4Corrupt
+			}
+			b := src[*i]
+			*i++
+			n += int(b)
+			if b != 255 {
+				return n, nil
+			}
+		}
+	}
+
+	for i := 0; i < len(src); {
+		token := src[i]
+		i++
+
+		literals, err := readLength(int(token>>4), &i)
+		if err != nil {
+			return nil, err
+		}
+		if i+literals > len(src) {
+			return nil, errLZ4Corrupt
+		}
+		dst = append(dst, src[i:i+literals]...)
+		i += literals
+		if i == len(src) {
+			break // 最后一个序列只有字面量
+		}
+
+		if i+2 > len(src) {
+			return nil, errLZ4Corrupt
+		}
+		offset := int(binary.LittleEndian.Uint16(src[i:]))
+		i += 2
+		if offset == 0 || offset > len(dst) {
+			return nil, errLZ4Corrupt
+		}
+		length, err := readLength(int(token&0x0F), &i)
+		if err != nil {
+			return nil, err
+		}
+		// 匹配可能与正在写入的数据重叠，逐字节复制
+		start := len(dst) - offset
+		for j := 0; j < length+4; j++ {
+			dst = append(dst, dst[start+j])
+		}
+	}
+	return dst, nil
+}
+
+// lz4DecompressFrame 解压 LZ4 帧
+func lz4DecompressFrame(src []byte) ([]byte, error) {
+	if len(src) < 7 || binary.LittleEndian.Uint32(src) != 0x184D2204 {
+		return nil, fmt.Errorf("%w: 不是 LZ4 帧", errLZ4Corrupt)
+	}
+	flg := src[4]
+	i := 6 // Magic, FLG, BD
+	if flg&0x08 != 0 {
+		i += 8 // 内容大小
+	}
+	if flg&0x01 != 0 {
+		i += 4 // 字典 ID
+	}
+	i++ // 头校验
+
+	var dst []byte
+	for {
+		if i+4 > len(src) {
+			return nil, errLZ4Corrupt
+		}
+		blockSize := binary.LittleEndian.Uint32(src[i:])
+		i += 4
+		if blockSize == 0 {
+			return dst, nil
+		}
+
+		n := int(blockSize & 0x7FFFFFFF)
+		if i+n > len(src) {
+			return nil, errLZ4Corrupt
+		}
+		block := src[i : i+n]
+		i += n
+		if blockSize&0x80000000 != 0 {
+			dst = append(dst, block...)
+		} else {
+			var err error
+			if dst, err = lz4AppendBlock(dst, block); err != nil {
+				return nil, err
+			}
+		}
+		if flg&0x10 != 0 {
+			i += 4 // 块校验
+		}
+	}
+}
+
+// cString 以 0 结尾的字符串
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
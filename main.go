@@ -1,12 +1,14 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"os"
-	"path/filepath"
-	"runtime"
 
 	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/driver/desktop"
 )
 
@@ -15,16 +17,24 @@ var (
 	BuildTime = "unknown" // 会被编译时注入
 )
 
-type Config struct {
-	NebulaURL   string `json:"nebula_url"`
-	DeviceID    string `json:"device_id"`
-	SavePath    string `json:"save_path"`
-	AutoSync    bool   `json:"auto_sync"`
-	AutoUpload  bool   `json:"auto_upload"`
-	AutoRestore bool   `json:"auto_restore"` // 游戏退出后自动恢复云端最新存档
-}
-
 func main() {
+	// 命令行参数
+	if len(os.Args) > 1 {
+		attachParentConsole()
+	}
+	opts, err := parseOptions(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+	setupDataDir(opts)
+
+	if len(opts.Command) > 0 {
+		os.Exit(runCommand(opts))
+	}
+
 	// 初始化日志系统
 	logFile, err := initLogger()
 	if err != nil {
@@ -35,23 +45,42 @@ func main() {
 
 	// 可以在日志或关于对话框中显示版本
 	log.Printf("BG3 存档同步客户端 v%s (构建时间: %s)\n", Version, BuildTime)
+	if portable {
+		log.Printf("便携模式，数据目录: %s\n", getAppDataDir())
+	}
 
 	// 创建 Fyne 应用
 	a := app.NewWithID("com.mosia.bg3sync")
 	a.SetIcon(resourceIconPng) // 你的图标
 
-	// 加载配置
-	config := loadConfig()
-	if config.SavePath == "" {
-		config.SavePath = getDefaultSavePath()
+	// 加载配置，环境变量和命令行参数优先
+	// 覆盖值有误时只使用配置文件的内容，不会只应用其中一部分
+	fileConfig, fileErr := loadConfig()
+	config, overrideErr := opts.effective(fileConfig)
+	configErr := errors.Join(fileErr, overrideErr)
+	if configErr != nil {
+		log.Printf("⚠️  %v\n", configErr)
 	}
 	if config.DeviceID == "" {
-		config.DeviceID = generateDeviceID()
-		saveConfig(config)
+		// 便携模式下第一台电脑沿用之前的设备ID，其他电脑生成各自的设备ID
+		config.DeviceID = fileConfig.DeviceID
+		if config.DeviceID == "" || len(fileConfig.DeviceIDs) > 0 {
+			config.DeviceID = generateDeviceID()
+		}
+		fileConfig.setDeviceID(config.DeviceID)
+
+		// 配置文件有误时读到的是默认配置，新的设备ID只在本次运行中使用，不覆盖原文件
+		if fileErr == nil {
+			if err := saveConfig(fileConfig); err != nil {
+				log.Printf("⚠️  %v\n", err)
+			}
+		} else {
+			log.Printf("配置文件有误，本次运行使用临时设备ID: %s\n", config.DeviceID)
+		}
 	}
 
 	// 创建客户端
-	client := NewClient(config, a)
+	client := NewClient(fileConfig, config, opts, a)
 
 	// 如果支持系统托盘
 	if desk, ok := a.(desktop.App); ok {
@@ -65,40 +94,31 @@ func main() {
 		}
 	}()
 
+	// 监听配置文件的外部修改
+	if err := client.watchConfigFile(); err != nil {
+		log.Printf("监听配置文件失败: %v\n", err)
+	}
+
+	// 定期清除过期的回收站存档
+	go client.monitorTrash()
+
+	// 定期校验云端存档
+	go client.monitorAudit()
+
 	// 显示主窗口
 	client.showMainWindow()
 
-	a.Run()
-}
-
-func getDefaultSavePath() string {
-	if runtime.GOOS == "windows" {
-		appData := os.Getenv("LOCALAPPDATA")
-		return filepath.Join(
-			appData,
-			"Larian Studios",
-			"Baldur's Gate 3",
-			"PlayerProfiles",
-			"Public",
-			"Savegames",
-			"Story",
-		)
-	} else if runtime.GOOS == "darwin" {
-		// macOS 路径
-		home, _ := os.UserHomeDir()
-		return filepath.Join(
-			home,
-			"Library",
-			"Application Support",
-			"Larian Studios",
-			"Baldur's Gate 3",
-			"PlayerProfiles",
-			"Public",
-			"Savegames",
-			"Story",
-		)
+	// 配置文件有误时提示
+	if configErr != nil {
+		dialog.ShowError(fmt.Errorf("配置有误: %w", configErr), client.mainWin)
+	} else if err := config.Validate(); err != nil {
+		log.Printf("⚠️  配置有误: %v\n", err)
+		dialog.ShowError(fmt.Errorf("配置有误，请在设置中修改:\n%w", err), client.mainWin)
+	} else if err := validateWritableDir(config.SavePath); err != nil {
+		// 新安装时游戏可能还没有创建存档目录，只提示不阻止使用
+		log.Printf("⚠️  存档路径不可用: %v\n", err)
+		client.statusBar.Set(fmt.Sprintf("存档路径不可用: %v", err))
 	}
-	// Linux 或其他系统
-	home, _ := os.UserHomeDir()
-	return filepath.Join(home, ".local", "share", "Larian Studios", "Baldur's Gate 3", "PlayerProfiles", "Public", "Savegames", "Story")
+
+	a.Run()
 }
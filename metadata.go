@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// parseTags 解析逗号或空格分隔的标签，去掉 # 前缀并去重
+func parseTags(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == '，' || r == ' ' || r == '　'
+	})
+
+	tags := []string{}
+	seen := make(map[string]bool)
+	for _, field := range fields {
+		tag := strings.TrimPrefix(strings.TrimSpace(field), "#")
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// formatTags 以 #tag 形式显示标签
+func formatTags(tags []string) string {
+	parts := make([]string, len(tags))
+	for i, tag := range tags {
+		parts[i] = "#" + tag
+	}
+	return strings.Join(parts, " ")
+}
+
+// matchesSearch 存档的备注、标签、存档名、角色名或战役是否包含关键字（不区分大小写）
+func matchesSearch(save *SaveGame, query string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return true
+	}
+
+	fields := []string{
+		save.Notes,
+		save.SaveName,
+		save.CharacterName,
+		save.CampaignName(),
+		strings.Join(save.Tags, " "),
+	}
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// showEditMetadata 编辑存档的备注和标签
+func (c *Client) showEditMetadata(save *SaveGame) {
+	win := c.app.NewWindow("编辑备注")
+	win.Resize(fyne.NewSize(420, 280))
+
+	notes := widget.NewMultiLineEntry()
+	notes.SetPlaceHolder("例如: 打安苏尔之前")
+	notes.SetText(save.Notes)
+
+	tags := widget.NewEntry()
+	tags.SetPlaceHolder("例如: act3, boss")
+	tags.SetText(strings.Join(save.Tags, ", "))
+
+	var saveBtn *widget.Button
+	saveBtn = widget.NewButton("保存", func() {
+		newNotes := strings.TrimSpace(notes.Text)
+		newTags := parseTags(tags.Text)
+		saveBtn.Disable()
+
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			updated, err := c.api.Load().UpdateSaveMetadata(ctx, save.ID, &SaveMetadataUpdate{
+				Notes: &newNotes,
+				Tags:  &newTags,
+			})
+			fyne.Do(func() {
+				if err != nil {
+					saveBtn.Enable()
+					dialog.ShowError(err, win)
+					return
+				}
+
+				save.Notes = updated.Notes
+				save.Tags = updated.Tags
+				if c.revisionList != nil {
+					c.revisionList.Refresh()
+				}
+				c.statusBar.Set("备注已保存")
+				win.Close()
+			})
+		}()
+	})
+
+	win.SetContent(container.NewPadded(container.NewBorder(
+		widget.NewLabel(fmt.Sprintf("%s - %s",
+			save.Timestamp.Format("2006-01-02 15:04:05"),
+			save.CampaignName(),
+		)),
+		container.NewVBox(
+			widget.NewForm(widget.NewFormItem("标签", tags)),
+			saveBtn,
+		),
+		nil, nil,
+		notes,
+	)))
+	win.Show()
+}
+
+// editLastUploadNotes 为最近一次上传的存档添加备注（托盘快捷操作）
+func (c *Client) editLastUploadNotes() {
+	save, _ := c.lastUpload()
+	if save == nil {
+		c.app.SendNotification(&fyne.Notification{
+			Title:   "BG3 存档同步",
+			Content: "还没有上传过存档",
+		})
+		return
+	}
+	c.showEditMetadata(save)
+}
@@ -0,0 +1,442 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 早期版本将模组信息放在上传的压缩包中的这个文件夹，恢复时不会解压到存档文件夹。
+// 现在模组信息作为上传请求中单独的 mods 字段发送，不再写入压缩包
+const syncMetaDir = "__bg3sync__"
+
+var (
+	modSettingsEntry = syncMetaDir + "/modsettings.lsx"
+	modManifestEntry = syncMetaDir + "/mods.json"
+)
+
+// ModPak Mods 文件夹中的一个 .pak 文件
+type ModPak struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	Hash string `json:"hash"` // SHA-256
+}
+
+// ModManifest 上传存档时 Mods 文件夹的清单
+type ModManifest struct {
+	Paks []ModPak `json:"paks"`
+}
+
+// ModInfo modsettings.lsx 中启用的一个模组
+type ModInfo struct {
+	UUID string
+	Name string
+}
+
+// ModSnapshot 存档对应的模组设置，上传时作为 mods 字段发送，由 GET /games/{id}/mods 取回
+type ModSnapshot struct {
+	Settings []byte       `json:"settings,omitempty"` // modsettings.lsx 原文，没有时为 nil
+	Manifest *ModManifest `json:"manifest,omitempty"` // 尚未计算完成时为 nil
+}
+
+// Mods 解析 modsettings.lsx 中按加载顺序排列的模组
+func (s *ModSnapshot) Mods() []ModInfo {
+	if s == nil || s.Settings == nil {
+		return nil
+	}
+	mods, err := parseModSettings(s.Settings)
+	if err != nil {
+		log.Printf("解析 modsettings.lsx 失败: %v\n", err)
+	}
+	return mods
+}
+
+// modSettingsPath modsettings.lsx 位于存档目录 (PlayerProfiles/<玩家档案>/Savegames/Story) 上两级
+func modSettingsPath(savePath string) string {
+	return filepath.Join(filepath.Dir(filepath.Dir(savePath)), "modsettings.lsx")
+}
+
+// modsDir Mods 文件夹位于 PlayerProfiles 同级
+func modsDir(savePath string) string {
+	return filepath.Join(filepath.Dir(filepath.Dir(filepath.Dir(filepath.Dir(savePath)))), "Mods")
+}
+
+// lsxNode .lsx 文件中的节点
+type lsxNode struct {
+	ID         string `xml:"id,attr"`
+	Attributes []struct {
+		ID    string `xml:"id,attr"`
+		Value string `xml:"value,attr"`
+	} `xml:"attribute"`
+	Children []lsxNode `xml:"children>node"`
+}
+
+// parseModSettings 读取 modsettings.lsx 中 Mods 节点下的模组
+func parseModSettings(data []byte) ([]ModInfo, error) {
+	var doc struct {
+		Regions []struct {
+			Nodes []lsxNode `xml:"node"`
+		} `xml:"region"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var mods []ModInfo
+	var walk func(node lsxNode, inMods bool)
+	walk = func(node lsxNode, inMods bool) {
+		if inMods && node.ID == "ModuleShortDesc" {
+			var mod ModInfo
+			for _, attr := range node.Attributes {
+				switch attr.ID {
+				case "UUID":
+					mod.UUID = attr.Value
+				case "Name":
+					mod.Name = attr.Value
+				}
+			}
+			mods = append(mods, mod)
+			return
+		}
+		for _, child := range node.Children {
+			walk(child, inMods || node.ID == "Mods")
+		}
+	}
+
+	for _, region := range doc.Regions {
+		for _, node := range region.Nodes {
+			walk(node, false)
+		}
+	}
+	return mods, nil
+}
+
+// pak 哈希缓存，按文件名、大小和修改时间判断是否需要重新计算
+type pakHashEntry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Hash    string    `json:"hash"`
+}
+
+var pakHashLock sync.Mutex
+
+func getPakHashCachePath() string {
+	return filepath.Join(getAppDataDir(), "mod_hash_cache.json")
+}
+
+// buildModManifest 列出 Mods 文件夹中的 .pak 文件，文件夹不存在时返回空清单
+func buildModManifest(dir string) (*ModManifest, error) {
+	pakHashLock.Lock()
+	defer pakHashLock.Unlock()
+
+	manifest := &ModManifest{}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取 Mods 文件夹失败: %w", err)
+	}
+
+	cache := make(map[string]pakHashEntry)
+	if data, err := os.ReadFile(getPakHashCachePath()); err == nil {
+		json.Unmarshal(data, &cache)
+	}
+	changed := false
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".pak") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		cached, ok := cache[entry.Name()]
+		if !ok || cached.Size != info.Size() || !cached.ModTime.Equal(info.ModTime()) {
+			hash, err := hashFile(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("计算 %s 哈希失败: %w", entry.Name(), err)
+			}
+			cached = pakHashEntry{Size: info.Size(), ModTime: info.ModTime(), Hash: hash}
+			cache[entry.Name()] = cached
+			changed = true
+		}
+
+		manifest.Paks = append(manifest.Paks, ModPak{Name: entry.Name(), Size: cached.Size, Hash: cached.Hash})
+	}
+
+	if changed {
+		if data, err := json.MarshalIndent(cache, "", "  "); err == nil {
+			os.WriteFile(getPakHashCachePath(), data, 0644)
+		}
+	}
+
+	sort.Slice(manifest.Paks, func(i, j int) bool {
+		return manifest.Paks[i].Name < manifest.Paks[j].Name
+	})
+	return manifest, nil
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// localModSnapshot 读取本机当前的模组设置，savePath 为存档所在的存档目录
+func localModSnapshot(savePath string) (*ModSnapshot, error) {
+	snap := &ModSnapshot{}
+
+	settings, err := os.ReadFile(modSettingsPath(savePath))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取 modsettings.lsx 失败: %w", err)
+	}
+	snap.Settings = settings
+
+	snap.Manifest, err = buildModManifest(modsDir(savePath))
+	if err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// modManifestCache 在后台计算 Mods 清单，上传时直接使用最近一次的结果，
+// 不会因为第一次计算所有 .pak 的哈希而阻塞上传
+type modManifestCache struct {
+	mu       sync.Mutex
+	dir      string
+	manifest *ModManifest
+	building bool
+}
+
+// get 返回 dir 最近一次计算的清单（还没有时为 nil），并在后台重新计算
+func (m *modManifestCache) get(dir string) *ModManifest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.dir != dir {
+		m.dir = dir
+		m.manifest = nil
+	}
+	manifest := m.manifest
+
+	if !m.building {
+		m.building = true
+		go m.build(dir)
+	}
+	return manifest
+}
+
+func (m *modManifestCache) build(dir string) {
+	manifest, err := buildModManifest(dir)
+	if err != nil {
+		log.Printf("⚠️  生成 Mods 清单失败: %v\n", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.building = false
+	if err == nil && m.dir == dir {
+		m.manifest = manifest
+	}
+}
+
+// uploadModSnapshot 上传时附带的模组设置：modsettings.lsx 立即读取，
+// Mods 清单使用后台计算的结果，失败时返回 nil
+func (c *Client) uploadModSnapshot(savePath string) *ModSnapshot {
+	settings, err := os.ReadFile(modSettingsPath(savePath))
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("⚠️  读取模组设置失败，上传时不包含模组信息: %v\n", err)
+		return nil
+	}
+
+	return &ModSnapshot{
+		Settings: settings,
+		Manifest: c.modManifests.get(modsDir(savePath)),
+	}
+}
+
+// isSyncMeta 压缩包中的文件是否是同步元数据而不是存档文件
+func isSyncMeta(name string) bool {
+	return strings.HasPrefix(strings.ReplaceAll(name, "\\", "/"), syncMetaDir+"/")
+}
+
+// modSnapshotFromArchive 读取早期版本写在压缩包中的模组信息，没有时返回 nil
+func modSnapshotFromArchive(data []byte) (*ModSnapshot, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	var snap *ModSnapshot
+	for _, file := range reader.File {
+		if file.Name != modSettingsEntry && file.Name != modManifestEntry {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if snap == nil {
+			snap = &ModSnapshot{}
+		}
+		if file.Name == modSettingsEntry {
+			snap.Settings = content
+		} else {
+			snap.Manifest = &ModManifest{}
+			if err := json.Unmarshal(content, snap.Manifest); err != nil {
+				return nil, fmt.Errorf("解析模组清单失败: %w", err)
+			}
+		}
+	}
+	return snap, nil
+}
+
+// ModComparison 云端存档的模组与本机模组的差异
+type ModComparison struct {
+	Cloud    *ModSnapshot
+	Problems []string
+}
+
+// CanRestoreSettings 云端存档带有 modsettings.lsx 且与本机不同
+func (m *ModComparison) CanRestoreSettings() bool {
+	return m != nil && m.Cloud.Settings != nil && len(m.Problems) > 0
+}
+
+// cloudModSnapshot 获取云端存档上传时的模组信息，服务端没有时读取压缩包中的旧格式
+func (c *Client) cloudModSnapshot(save *SaveGame, data []byte) (*ModSnapshot, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	snap, err := c.api.Load().GetModSnapshot(ctx, save.ID)
+	if err != nil || snap != nil {
+		return snap, err
+	}
+	return modSnapshotFromArchive(data)
+}
+
+// compareMods 对比云端存档和本机的模组，云端存档没有模组信息时返回 nil
+func (c *Client) compareMods(save *SaveGame, data []byte, savePath string) (*ModComparison, error) {
+	cloud, err := c.cloudModSnapshot(save, data)
+	if err != nil || cloud == nil {
+		return nil, err
+	}
+
+	local, err := localModSnapshot(savePath)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ModComparison{Cloud: cloud}
+	add := func(format string, args ...any) {
+		result.Problems = append(result.Problems, fmt.Sprintf(format, args...))
+	}
+
+	// 启用的模组和加载顺序
+	if cloud.Settings != nil {
+		cloudMods, localMods := cloud.Mods(), local.Mods()
+		localIndex := make(map[string]int)
+		for i, mod := range localMods {
+			localIndex[mod.UUID] = i
+		}
+		cloudIndex := make(map[string]bool)
+		for _, mod := range cloudMods {
+			cloudIndex[mod.UUID] = true
+			if _, ok := localIndex[mod.UUID]; !ok {
+				add("本机未启用模组: %s", mod.Name)
+			}
+		}
+		for _, mod := range localMods {
+			if !cloudIndex[mod.UUID] {
+				add("本机额外启用了模组: %s", mod.Name)
+			}
+		}
+		if len(result.Problems) == 0 && !sameModOrder(cloudMods, localMods) {
+			add("模组加载顺序不同")
+		}
+	}
+
+	// Mods 文件夹中的 pak 文件
+	if cloud.Manifest != nil {
+		localPaks := make(map[string]ModPak)
+		for _, pak := range local.Manifest.Paks {
+			localPaks[strings.ToLower(pak.Name)] = pak
+		}
+		for _, pak := range cloud.Manifest.Paks {
+			localPak, ok := localPaks[strings.ToLower(pak.Name)]
+			switch {
+			case !ok:
+				add("本机缺少模组文件: %s", pak.Name)
+			case localPak.Hash != pak.Hash:
+				add("模组文件版本不同: %s", pak.Name)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func sameModOrder(a, b []ModInfo) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].UUID != b[i].UUID {
+			return false
+		}
+	}
+	return true
+}
+
+// restoreModSettings 用云端存档的 modsettings.lsx 覆盖本机设置，覆盖前备份
+func (c *Client) restoreModSettings(snap *ModSnapshot, savePath string) error {
+	path := modSettingsPath(savePath)
+
+	if current, err := os.ReadFile(path); err == nil {
+		dir := filepath.Join(getBackupsDir(), syncMetaDir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建备份目录失败: %w", err)
+		}
+		backupPath := filepath.Join(dir, "modsettings-"+time.Now().Format(localBackupTimeFormat)+".lsx")
+		if err := os.WriteFile(backupPath, current, 0644); err != nil {
+			return fmt.Errorf("备份 modsettings.lsx 失败: %w", err)
+		}
+		log.Printf("已备份模组设置: %s\n", backupPath)
+	}
+
+	if err := os.WriteFile(path, snap.Settings, 0644); err != nil {
+		return fmt.Errorf("写入 modsettings.lsx 失败: %w", err)
+	}
+	log.Printf("已恢复模组设置: %s\n", path)
+	return nil
+}
@@ -4,10 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"math/rand/v2"
 	"mime/multipart"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,20 +23,257 @@ type NebulaAPI struct {
 	baseURL  string
 	deviceID string
 	client   *http.Client
+	throttle *throttledTransport
+	retry    RetryPolicy
+	timeouts Timeouts
+
+	compress     bool        // 压缩请求体
+	gzipAccepted atomic.Bool // 服务端声明接受 gzip 请求体
+	gzipRejected atomic.Bool // 服务端返回过 415，不接受压缩的请求体
 }
 
-func NewNebulaAPI(baseURL, deviceID string) *NebulaAPI {
+// NewNebulaAPI 按网络设置（代理、证书、超时）创建 API 客户端
+func NewNebulaAPI(baseURL, deviceID string, network NetworkConfig) (*NebulaAPI, error) {
+	base, err := buildTransport(network)
+	if err != nil {
+		return nil, err
+	}
+	throttle := newThrottledTransport(base)
+
 	return &NebulaAPI{
 		baseURL:  baseURL,
 		deviceID: deviceID,
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Transport: throttle,
 		},
+		throttle: throttle,
+		retry:    defaultRetryPolicy(),
+		timeouts: network.Timeouts,
+		compress: network.Compression,
+	}, nil
+}
+
+// SetBandwidth 设置上传和下载速率（字节/秒），0 表示不限速，对进行中的传输立即生效
+func (api *NebulaAPI) SetBandwidth(upload, download int64) {
+	api.throttle.upload.SetRate(upload)
+	api.throttle.download.SetRate(download)
+}
+
+// RetryPolicy 请求失败时的重试策略（指数退避 + 随机抖动）
+type RetryPolicy struct {
+	MaxAttempts int           // 最多尝试次数（含第一次）
+	BaseDelay   time.Duration // 第一次重试前的最长等待
+	MaxDelay    time.Duration // 单次等待上限，Retry-After 也不超过该值
+}
+
+func defaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+	}
+}
+
+// backoff 第 attempt 次失败后的等待时间；服务端给出 Retry-After 时以其为准
+func (p RetryPolicy) backoff(attempt int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return min(apiErr.RetryAfter, p.MaxDelay)
+	}
+
+	ceiling := p.BaseDelay << (attempt - 1)
+	if ceiling <= 0 || ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+	return time.Duration(rand.Int64N(int64(ceiling))) + 1
+}
+
+// retryable 网络错误、超时、限流和服务端临时错误可以重试
+func retryable(err error) bool {
+	if errors.Is(err, ErrNetwork) {
+		return true
+	}
+	switch code := statusCode(err); {
+	case code == http.StatusTooManyRequests:
+		return true
+	case code >= 500 && code != http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// apiRequest 一次 API 调用
+type apiRequest struct {
+	op          string // 操作名称，用于错误信息，如 "上传"
+	method      string
+	path        string
+	body        []byte
+	contentType string
+	ok          []int        // 视为成功的状态码，默认只有 200
+	retry       bool         // 只有幂等请求允许自动重试
+	transfer    bool         // 上传或下载存档，使用传输超时
+	progress    ProgressFunc // 上传进度，可为 nil
+
+	encoding  string // 请求体的 Content-Encoding，由 compressBody 设置
+	plainBody []byte // 压缩前的请求体
+}
+
+// do 发送请求，失败时按重试策略重试；成功时由调用方关闭响应体。
+// 非成功状态码返回 *APIError，没有得到响应返回 *NetworkError
+func (api *NebulaAPI) do(ctx context.Context, r *apiRequest) (*http.Response, error) {
+	attempts := 1
+	if r.retry {
+		attempts = max(api.retry.MaxAttempts, 1)
+	}
+
+	api.compressBody(r)
+
+	for attempt := 1; ; attempt++ {
+		resp, err := api.send(ctx, r)
+		if err == nil {
+			return resp, nil
+		}
+		if r.encoding != "" && statusCode(err) == http.StatusUnsupportedMediaType {
+			// 服务端不接受压缩，立即以未压缩的请求体重发，不计入重试次数
+			api.rejectCompression(r)
+			attempt--
+			continue
+		}
+		if attempt >= attempts || !retryable(err) || ctx.Err() != nil {
+			return nil, err
+		}
+
+		delay := api.retry.backoff(attempt, err)
+		log.Printf("⚠️  %s失败，%v 后重试 (%d/%d): %v\n", r.op, delay.Round(time.Millisecond), attempt, attempts-1, err)
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(delay):
+		}
 	}
 }
 
-// UploadSave 上传存档到云端
-func (api *NebulaAPI) UploadSave(ctx context.Context, fileName string, data []byte) (*SaveGame, error) {
+func (api *NebulaAPI) send(ctx context.Context, r *apiRequest) (*http.Response, error) {
+	timeout := api.timeouts.Request()
+	if r.transfer {
+		timeout = api.timeouts.Transfer()
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+
+	resp, err := api.sendWithContext(ctx, r)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// 超时覆盖读取响应体的时间，关闭响应体时释放
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+func (api *NebulaAPI) sendWithContext(ctx context.Context, r *apiRequest) (*http.Response, error) {
+	var body io.Reader
+	if r.body != nil {
+		body = bytes.NewReader(r.body)
+		if r.progress != nil {
+			body = &progressReader{r: body, total: int64(len(r.body)), progress: r.progress}
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, api.baseURL+r.path, body)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	if r.body != nil {
+		req.ContentLength = int64(len(r.body))
+	}
+
+	if r.contentType != "" {
+		req.Header.Set("Content-Type", r.contentType)
+	}
+	if r.encoding != "" {
+		req.Header.Set("Content-Encoding", r.encoding)
+	}
+	// 列表等 JSON 响应请求 gzip 压缩；存档本身已压缩，HEAD 需要原始大小
+	if !r.transfer && r.method != "HEAD" {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	req.Header.Set("X-Device-ID", api.deviceID)
+
+	resp, err := api.client.Do(req)
+	if err != nil {
+		return nil, &NetworkError{Op: r.op, Err: err}
+	}
+
+	// 代理或网关返回的 5xx 错误不代表服务端的能力
+	if resp.StatusCode < http.StatusInternalServerError {
+		api.noteAcceptEncoding(resp.Header)
+	}
+	if err := decodeResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, &NetworkError{Op: r.op, Err: fmt.Errorf("解压响应失败: %w", err)}
+	}
+
+	ok := r.ok
+	if ok == nil {
+		ok = []int{http.StatusOK}
+	}
+	if slices.Contains(ok, resp.StatusCode) {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	return nil, &APIError{
+		Op:         r.op,
+		StatusCode: resp.StatusCode,
+		Message:    errorMessage(resp.Body),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// cancelOnClose 关闭响应体时取消请求的 context
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// errorMessage 读取错误响应，优先使用服务端返回的 JSON 错误信息
+func errorMessage(r io.Reader) string {
+	body, _ := io.ReadAll(io.LimitReader(r, 4096))
+
+	var errResp ErrorResponse
+	if json.Unmarshal(body, &errResp) == nil && errResp.Error != "" {
+		if errResp.Message != "" {
+			return errResp.Error + ": " + errResp.Message
+		}
+		return errResp.Error
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// getJSON 发送幂等的 GET 请求并解析 JSON 响应
+func (api *NebulaAPI) getJSON(ctx context.Context, op, path string, v any) error {
+	resp, err := api.do(ctx, &apiRequest{op: op, method: "GET", path: path, retry: true})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	return nil
+}
+
+// UploadSave 上传存档到云端，info 为本地解析的存档信息，info 和 progress 都可为 nil
+func (api *NebulaAPI) UploadSave(ctx context.Context, fileName string, data []byte, info *SaveInfo, progress ProgressFunc) (*SaveGame, error) {
 	var buf bytes.Buffer
 	writer := multipart.NewWriter(&buf)
 
@@ -45,42 +290,47 @@ func (api *NebulaAPI) UploadSave(ctx context.Context, fileName string, data []by
 	// 添加元数据（可选）
 	writer.WriteField("device_id", api.deviceID)
 	writer.WriteField("timestamp", time.Now().Format(time.RFC3339))
+	if info != nil {
+		writer.WriteField("campaign", info.Folder)
+		writer.WriteField("game_mode", info.GameMode)
+		writer.WriteField("save_name", info.SaveName)
+		writer.WriteField("profile", info.Profile)
+		writer.WriteField("game", info.Game)
+		if info.LeaderName != "" {
+			writer.WriteField("character_name", info.LeaderName)
+		}
+		if info.GameTime > 0 {
+			writer.WriteField("game_time", strconv.Itoa(info.GameTime))
+		}
 
-	if err := writer.Close(); err != nil {
-		return nil, fmt.Errorf("关闭writer失败: %w", err)
+		// 模组信息作为单独的字段，不影响旧版本客户端解压存档
+		if info.Mods != nil {
+			if mods, err := json.Marshal(info.Mods); err == nil {
+				writer.WriteField("mods", string(mods))
+			}
+		}
 	}
 
-	// 创建请求
-	req, err := http.NewRequestWithContext(
-		ctx,
-		"POST",
-		fmt.Sprintf("%s/games/upload", api.baseURL),
-		&buf,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("关闭writer失败: %w", err)
 	}
 
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	req.Header.Set("X-Device-ID", api.deviceID)
-
-	// 发送请求
-	resp, err := api.client.Do(req)
+	// 上传不是幂等操作，失败后不自动重试，由下一次存档变化重新上传
+	resp, err := api.do(ctx, &apiRequest{
+		op:          "上传",
+		method:      "POST",
+		path:        "/games/upload",
+		body:        buf.Bytes(),
+		contentType: writer.FormDataContentType(),
+		ok:          []int{http.StatusOK, http.StatusCreated},
+		transfer:    true,
+		progress:    progress,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("发送请求失败: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	// 检查响应状态
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		var errResp ErrorResponse
-		if json.Unmarshal(body, &errResp) == nil && errResp.Error != "" {
-			return nil, fmt.Errorf("服务器错误: %s", errResp.Error)
-		}
-		return nil, fmt.Errorf("上传失败 (状态码: %d): %s", resp.StatusCode, string(body))
-	}
-
 	// 解析响应
 	var uploadResp UploadResponse
 	if err := json.NewDecoder(resp.Body).Decode(&uploadResp); err != nil {
@@ -90,123 +340,258 @@ func (api *NebulaAPI) UploadSave(ctx context.Context, fileName string, data []by
 	return uploadResp.Save, nil
 }
 
-// ListSaves 获取存档列表
-func (api *NebulaAPI) ListSaves(ctx context.Context, limit int) ([]*SaveGame, error) {
-	url := fmt.Sprintf("%s/games/list?limit=%d", api.baseURL, limit)
+// ListSaves 获取存档列表（按游标分页，支持服务端过滤）
+func (api *NebulaAPI) ListSaves(ctx context.Context, opts ListSavesOptions) (*SaveGameListResponse, error) {
+	var listResp SaveGameListResponse
+	if err := api.getJSON(ctx, "获取列表", "/games/list?"+opts.query().Encode(), &listResp); err != nil {
+		return nil, err
+	}
+	return &listResp, nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
+// ListAllSaves 按游标翻页获取所有符合条件的存档
+func (api *NebulaAPI) ListAllSaves(ctx context.Context, opts ListSavesOptions) ([]*SaveGame, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = 100
 	}
 
-	req.Header.Set("X-Device-ID", api.deviceID)
+	var saves []*SaveGame
+	for {
+		listResp, err := api.ListSaves(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
 
-	resp, err := api.client.Do(req)
+		saves = append(saves, listResp.Saves...)
+		if listResp.NextCursor == "" || len(listResp.Saves) == 0 {
+			return saves, nil
+		}
+		opts.Cursor = listResp.NextCursor
+	}
+}
+
+// query 将查询参数编码为 URL 参数，零值字段不发送
+func (o ListSavesOptions) query() url.Values {
+	q := url.Values{}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Cursor != "" {
+		q.Set("cursor", o.Cursor)
+	}
+	if o.Folder != "" {
+		q.Set("folder", o.Folder)
+	}
+	if o.DeviceID != "" {
+		q.Set("device_id", o.DeviceID)
+	}
+	if !o.Since.IsZero() {
+		q.Set("since", o.Since.Format(time.RFC3339))
+	}
+	if !o.Until.IsZero() {
+		q.Set("until", o.Until.Format(time.RFC3339))
+	}
+	if o.Tag != "" {
+		q.Set("tag", o.Tag)
+	}
+	if o.GameMode != "" {
+		q.Set("game_mode", o.GameMode)
+	}
+	return q
+}
+
+// DownloadSave 下载存档，progress 可为 nil
+func (api *NebulaAPI) DownloadSave(ctx context.Context, saveID string, progress ProgressFunc) ([]byte, error) {
+	resp, err := api.do(ctx, &apiRequest{
+		op:       "下载",
+		method:   "GET",
+		path:     fmt.Sprintf("/games/%s/download", saveID),
+		retry:    true,
+		transfer: true,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("发送请求失败: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("获取列表失败 (状态码: %d): %s", resp.StatusCode, string(body))
+	var body io.Reader = resp.Body
+	if progress != nil {
+		body = &progressReader{r: resp.Body, total: resp.ContentLength, progress: progress}
 	}
 
-	var listResp SaveGameListResponse
-	if err := json.NewDecoder(resp.Body).Decode(&listResp); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, &NetworkError{Op: "下载", Err: fmt.Errorf("读取响应失败: %w", err)}
 	}
 
-	return listResp.Saves, nil
+	return data, nil
 }
 
-// DownloadSave 下载存档
-func (api *NebulaAPI) DownloadSave(ctx context.Context, saveID string) ([]byte, error) {
-	url := fmt.Sprintf("%s/games/%s/download", api.baseURL, saveID)
+// HeadSave 检查存档文件是否存在，返回服务端报告的文件大小（未知时为 -1）
+func (api *NebulaAPI) HeadSave(ctx context.Context, saveID string) (exists bool, size int64, err error) {
+	resp, err := api.do(ctx, &apiRequest{
+		op:     "检查存档",
+		method: "HEAD",
+		path:   fmt.Sprintf("/games/%s/download", saveID),
+		retry:  true,
+	})
+	if errors.Is(err, ErrNotFound) {
+		return false, -1, nil
+	}
+	if err != nil {
+		return false, -1, err
+	}
+	defer resp.Body.Close()
+
+	return true, resp.ContentLength, nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+// DeleteSave 删除存档，服务端拒绝删除已固定的存档时返回的错误匹配 ErrPinned
+func (api *NebulaAPI) DeleteSave(ctx context.Context, saveID string) error {
+	resp, err := api.do(ctx, &apiRequest{
+		op:     "删除",
+		method: "DELETE",
+		path:   fmt.Sprintf("/games/%s", saveID),
+		ok:     []int{http.StatusOK, http.StatusNoContent},
+		retry:  true,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
+		return err
 	}
+	resp.Body.Close()
+	return nil
+}
 
-	req.Header.Set("X-Device-ID", api.deviceID)
+// UpdateSaveMetadata 更新存档的备注、标签和固定状态
+func (api *NebulaAPI) UpdateSaveMetadata(ctx context.Context, saveID string, update *SaveMetadataUpdate) (*SaveGame, error) {
+	body, err := json.Marshal(update)
+	if err != nil {
+		return nil, fmt.Errorf("编码请求失败: %w", err)
+	}
 
-	resp, err := api.client.Do(req)
+	// 只设置字段的新值，重复提交结果相同，可以重试
+	resp, err := api.do(ctx, &apiRequest{
+		op:          "更新存档",
+		method:      "PATCH",
+		path:        fmt.Sprintf("/games/%s/metadata", saveID),
+		body:        body,
+		contentType: "application/json",
+		retry:       true,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("发送请求失败: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("下载失败 (状态码: %d): %s", resp.StatusCode, string(body))
+	var save SaveGame
+	if err := json.NewDecoder(resp.Body).Decode(&save); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
 	}
 
-	data, err := io.ReadAll(resp.Body)
+	return &save, nil
+}
+
+// TrashSave 将存档移入服务端回收站
+func (api *NebulaAPI) TrashSave(ctx context.Context, saveID string) error {
+	resp, err := api.do(ctx, &apiRequest{
+		op:     "移入回收站",
+		method: "POST",
+		path:   fmt.Sprintf("/games/%s/trash", saveID),
+		ok:     []int{http.StatusOK, http.StatusNoContent},
+	})
+	if api.trashUnsupported(ctx, err) {
+		return ErrTrashUnsupported
+	}
 	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
+		return err
 	}
-
-	return data, nil
+	resp.Body.Close()
+	return nil
 }
 
-// DeleteSave 删除存档
-func (api *NebulaAPI) DeleteSave(ctx context.Context, saveID string) error {
-	url := fmt.Sprintf("%s/games/%s", api.baseURL, saveID)
+// trashUnsupported 判断移入回收站失败是否因为服务端没有回收站。
+// 404 也可能是存档不存在，此时通过获取回收站列表确认，避免把不存在的存档当作不支持回收站
+func (api *NebulaAPI) trashUnsupported(ctx context.Context, err error) bool {
+	switch statusCode(err) {
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	case http.StatusNotFound:
+		_, listErr := api.ListTrash(ctx)
+		return errors.Is(listErr, ErrTrashUnsupported)
+	}
+	return false
+}
 
-	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
+// ListTrash 获取服务端回收站中的存档
+func (api *NebulaAPI) ListTrash(ctx context.Context) ([]*SaveGame, error) {
+	var listResp SaveGameListResponse
+	err := api.getJSON(ctx, "获取回收站", "/games/trash", &listResp)
+	if endpointUnsupported(err) {
+		return nil, ErrTrashUnsupported
+	}
 	if err != nil {
-		return fmt.Errorf("创建请求失败: %w", err)
+		return nil, err
 	}
+	return listResp.Saves, nil
+}
 
-	req.Header.Set("X-Device-ID", api.deviceID)
-
-	resp, err := api.client.Do(req)
+// UntrashSave 从服务端回收站恢复存档
+func (api *NebulaAPI) UntrashSave(ctx context.Context, saveID string) error {
+	resp, err := api.do(ctx, &apiRequest{
+		op:     "从回收站恢复",
+		method: "POST",
+		path:   fmt.Sprintf("/games/%s/untrash", saveID),
+		ok:     []int{http.StatusOK, http.StatusNoContent},
+	})
 	if err != nil {
-		return fmt.Errorf("发送请求失败: %w", err)
+		return err
 	}
-	defer resp.Body.Close()
+	resp.Body.Close()
+	return nil
+}
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("删除失败 (状态码: %d): %s", resp.StatusCode, string(body))
+// GetModSnapshot 获取存档上传时的模组信息，服务端没有记录或不支持时返回 nil
+func (api *NebulaAPI) GetModSnapshot(ctx context.Context, saveID string) (*ModSnapshot, error) {
+	var snap ModSnapshot
+	err := api.getJSON(ctx, "获取模组信息", fmt.Sprintf("/games/%s/mods", saveID), &snap)
+	if endpointUnsupported(err) {
+		return nil, nil
 	}
+	if err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
 
-	return nil
+// 回收站等可选接口不存在时服务端返回的状态码
+func endpointUnsupported(err error) bool {
+	switch statusCode(err) {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
 }
 
 // GetLatestSave 获取最新的存档
 func (api *NebulaAPI) GetLatestSave(ctx context.Context) (*SaveGame, error) {
-	saves, err := api.ListSaves(ctx, 1)
+	listResp, err := api.ListSaves(ctx, ListSavesOptions{Limit: 1})
 	if err != nil {
 		return nil, err
 	}
 
-	if len(saves) == 0 {
+	if len(listResp.Saves) == 0 {
 		return nil, fmt.Errorf("没有找到存档")
 	}
 
-	return saves[0], nil
+	return listResp.Saves[0], nil
 }
 
-// CheckHealth 检查服务器健康状态
+// CheckHealth 检查服务器健康状态（由 monitorHealth 定期调用，不重试）
 func (api *NebulaAPI) CheckHealth(ctx context.Context) error {
-	url := fmt.Sprintf("%s/health", api.baseURL)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	resp, err := api.do(ctx, &apiRequest{op: "健康检查", method: "GET", path: "/health"})
 	if err != nil {
-		return fmt.Errorf("创建请求失败: %w", err)
+		return err
 	}
-
-	resp, err := api.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("发送请求失败: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("服务器不健康 (状态码: %d)", resp.StatusCode)
-	}
-
+	resp.Body.Close()
 	return nil
 }
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// 配置的优先级（后者覆盖前者）：默认值 < config.json < BG3SYNC_* 环境变量 < 命令行参数。
+// 每个配置项都有对应的参数和环境变量，名称由 json 字段名生成，嵌套的字段用 - 或 _ 连接，
+// 如 network.timeouts.connect_seconds 对应 -network-timeouts-connect-seconds
+// 和 BG3SYNC_NETWORK_TIMEOUTS_CONNECT_SECONDS
+
+const envPrefix = "BG3SYNC_"
+
+// configOption 一个可以被覆盖的配置项
+type configOption struct {
+	Name  string // 命令行参数名
+	Env   string // 环境变量名
+	index []int  // Config 中的字段位置
+	kind  reflect.Type
+}
+
+// configOptions 列出 Config 的所有配置项，结构体字段展开为各自的子项
+func configOptions() []configOption {
+	var options []configOption
+	var walk func(t reflect.Type, prefix []string, index []int)
+	walk = func(t reflect.Type, prefix []string, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			// 版本号和便携模式的设备ID由程序维护，不能覆盖
+			if name == "" || name == "-" || name == "version" || name == "device_ids" {
+				continue
+			}
+
+			path := append(append([]string{}, prefix...), name)
+			fieldIndex := append(append([]int{}, index...), i)
+			if field.Type.Kind() == reflect.Struct {
+				walk(field.Type, path, fieldIndex)
+				continue
+			}
+
+			key := strings.Join(path, "_")
+			options = append(options, configOption{
+				Name:  strings.ReplaceAll(key, "_", "-"),
+				Env:   envPrefix + strings.ToUpper(key),
+				index: fieldIndex,
+				kind:  field.Type,
+			})
+		}
+	}
+	walk(reflect.TypeOf(Config{}), nil, nil)
+	return options
+}
+
+// usage 参数说明
+func (o configOption) usage() string {
+	switch o.kind.Kind() {
+	case reflect.String:
+		return "字符串，环境变量 " + o.Env
+	case reflect.Bool:
+		return "true/false，环境变量 " + o.Env
+	case reflect.Int, reflect.Int64:
+		return "整数，环境变量 " + o.Env
+	}
+	if o.kind == reflect.TypeOf([]string{}) {
+		return "逗号分隔的列表，环境变量 " + o.Env
+	}
+	return "JSON，环境变量 " + o.Env
+}
+
+// set 将文本值写入配置
+func (o configOption) set(cfg *Config, value string) error {
+	field := reflect.ValueOf(cfg).Elem().FieldByIndex(o.index)
+
+	switch {
+	case o.kind.Kind() == reflect.String:
+		field.SetString(value)
+	case o.kind.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s 需要 true 或 false: %q", o.Name, value)
+		}
+		field.SetBool(b)
+	case o.kind.Kind() == reflect.Int || o.kind.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s 需要整数: %q", o.Name, value)
+		}
+		field.SetInt(n)
+	case o.kind == reflect.TypeOf([]string{}):
+		items := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		if err := json.Unmarshal([]byte(value), field.Addr().Interface()); err != nil {
+			return fmt.Errorf("%s 需要 JSON: %w", o.Name, err)
+		}
+	}
+	return nil
+}
+
+// Options 命令行参数
+type Options struct {
+	DataDir  string   // 数据目录，为空使用默认位置
+	Portable bool     // 便携模式，数据保存在程序旁
+	Command  []string // 子命令，如 config show
+
+	flags []configValue // 命令行中指定的配置项，按出现顺序
+}
+
+type configValue struct {
+	option configOption
+	value  string
+}
+
+// parseOptions 解析命令行参数，参数可以出现在子命令前后
+func parseOptions(args []string) (*Options, error) {
+	opts := &Options{DataDir: os.Getenv(envPrefix + "DATA_DIR")}
+	opts.Portable, _ = strconv.ParseBool(os.Getenv(envPrefix + "PORTABLE"))
+
+	fs := flag.NewFlagSet("bg3sync", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "用法: bg3sync [参数] [config show]\n\n")
+		fmt.Fprintf(fs.Output(), "优先级: 默认值 < config.json < %s* 环境变量 < 命令行参数\n\n", envPrefix)
+		fs.PrintDefaults()
+	}
+
+	fs.StringVar(&opts.DataDir, "data-dir", opts.DataDir, "数据目录（配置、日志和缓存），环境变量 "+envPrefix+"DATA_DIR")
+	fs.BoolVar(&opts.Portable, "portable", opts.Portable, "便携模式，数据保存在程序旁的 "+portableDataDir+" 目录，环境变量 "+envPrefix+"PORTABLE")
+	for _, option := range configOptions() {
+		record := func(value string) error {
+			opts.flags = append(opts.flags, configValue{option, value})
+			return nil
+		}
+		if option.kind.Kind() == reflect.Bool {
+			fs.BoolFunc(option.Name, option.usage(), record)
+		} else {
+			fs.Func(option.Name, option.usage(), record)
+		}
+	}
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		opts.Command = append(opts.Command, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	return opts, nil
+}
+
+// apply 依次应用环境变量和命令行参数
+func (opts *Options) apply(cfg *Config) error {
+	for _, option := range configOptions() {
+		if value, ok := os.LookupEnv(option.Env); ok {
+			if err := option.set(cfg, value); err != nil {
+				return fmt.Errorf("环境变量 %s 无效: %w", option.Env, err)
+			}
+		}
+	}
+	for _, v := range opts.flags {
+		if err := v.option.set(cfg, v.value); err != nil {
+			return fmt.Errorf("参数 -%w", err)
+		}
+	}
+	return nil
+}
+
+// effective 在配置文件的内容上应用环境变量和命令行参数，得到实际使用的配置。
+// 返回的是副本，保存配置时只保存配置文件的内容，覆盖值不会被写入 config.json。
+// 覆盖值有误时一个也不应用，返回只使用配置文件的配置和错误
+func (opts *Options) effective(file *Config) (*Config, error) {
+	config := file.clone()
+	portableDeviceID(config)
+	overridden := config.clone()
+	err := opts.apply(overridden)
+	if err == nil {
+		config = overridden
+	}
+	if config.SavePath == "" {
+		config.SavePath = gameByID(config.Game).DefaultSavePath()
+	}
+	portableSavePath(config)
+	return config, err
+}
+
+// loadEffectiveConfig 读取配置文件，返回文件中的配置和应用环境变量、命令行参数后实际使用的配置
+func loadEffectiveConfig(opts *Options) (file, config *Config, err error) {
+	file, err = loadConfig()
+	config, applyErr := opts.effective(file)
+	if err == nil {
+		err = applyErr
+	}
+	return file, config, err
+}
+
+// mergeEdits 将 edited 相对 base 修改过的配置项写入 file，
+// 用户没有修改的配置项（包括被环境变量和命令行参数覆盖的）保持配置文件中的值
+func mergeEdits(file, base, edited *Config) {
+	for _, option := range configOptions() {
+		before := reflect.ValueOf(base).Elem().FieldByIndex(option.index)
+		after := reflect.ValueOf(edited).Elem().FieldByIndex(option.index)
+		if !reflect.DeepEqual(before.Interface(), after.Interface()) {
+			reflect.ValueOf(file).Elem().FieldByIndex(option.index).Set(after)
+		}
+	}
+}
+
+// runCommand 执行子命令，返回进程退出码
+func runCommand(opts *Options) int {
+	switch strings.Join(opts.Command, " ") {
+	case "config show":
+		_, config, err := loadEffectiveConfig(opts)
+		fmt.Fprintf(os.Stderr, "数据目录: %s\n配置文件: %s\n", getAppDataDir(), getConfigPath())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		}
+
+		data, _ := json.MarshalIndent(config, "", "  ")
+		fmt.Println(string(data))
+		if err != nil {
+			return 1
+		}
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "未知命令: %s\n", strings.Join(opts.Command, " "))
+		return 2
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func findOption(t *testing.T, name string) configOption {
+	t.Helper()
+	for _, option := range configOptions() {
+		if option.Name == name {
+			return option
+		}
+	}
+	t.Fatalf("找不到配置项 %s", name)
+	return configOption{}
+}
+
+func TestConfigOptions(t *testing.T) {
+	names := map[string]string{}
+	for _, option := range configOptions() {
+		names[option.Name] = option.Env
+	}
+
+	tests := []struct {
+		name string
+		env  string
+	}{
+		{"nebula-url", "BG3SYNC_NEBULA_URL"},
+		{"auto-sync", "BG3SYNC_AUTO_SYNC"},
+		{"sync-profiles", "BG3SYNC_SYNC_PROFILES"},
+		{"retention-keep-last", "BG3SYNC_RETENTION_KEEP_LAST"},
+		{"network-timeouts-connect-seconds", "BG3SYNC_NETWORK_TIMEOUTS_CONNECT_SECONDS"},
+	}
+	for _, tt := range tests {
+		if env, ok := names[tt.name]; !ok || env != tt.env {
+			t.Errorf("%s: 环境变量 = %q, want %q", tt.name, env, tt.env)
+		}
+	}
+
+	// 版本号、便携模式的设备ID和结构体本身不是配置项
+	for _, name := range []string{"version", "device-ids", "retention", "network", "network-timeouts"} {
+		if _, ok := names[name]; ok {
+			t.Errorf("%s 不应该是配置项", name)
+		}
+	}
+}
+
+func TestConfigOptionSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		option  string
+		value   string
+		want    func(cfg *Config) any
+		expect  any
+		wantErr bool
+	}{
+		{"字符串", "nebula-url", "http://nas:8080", func(cfg *Config) any { return cfg.NebulaURL }, "http://nas:8080", false},
+		{"布尔值", "auto-sync", "false", func(cfg *Config) any { return cfg.AutoSync }, false, false},
+		{"无效的布尔值", "auto-sync", "maybe", nil, nil, true},
+		{"整数", "network-timeouts-connect-seconds", " 15 ", func(cfg *Config) any { return cfg.Network.Timeouts.ConnectSeconds }, 15, false},
+		{"无效的整数", "retention-keep-last", "abc", nil, nil, true},
+		{"列表忽略空项", "game-modes", "a, ,b", func(cfg *Config) any { return cfg.GameModes }, []string{"a", "b"}, false},
+		{"空列表", "mirrors", "", func(cfg *Config) any { return cfg.Mirrors }, []string{}, false},
+		{"JSON", "sync-profiles", `[{"name":"NAS","nebula_url":"http://nas:8080"}]`, func(cfg *Config) any {
+			return []string{cfg.SyncProfiles[0].Name, cfg.SyncProfiles[0].NebulaURL}
+		}, []string{"NAS", "http://nas:8080"}, false},
+		{"无效的 JSON", "sync-profiles", "[", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{AutoSync: true}
+			err := findOption(t, tt.option).set(cfg, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("set() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := tt.want(cfg); !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("set() = %#v, want %#v", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestMergeEdits(t *testing.T) {
+	file := &Config{NebulaURL: "http://file", SavePath: "", GameModes: []string{"a"}}
+	// 实际使用的配置中服务器地址被命令行参数覆盖，存档路径使用了默认值
+	base := file.clone()
+	base.NebulaURL = "http://flag"
+	base.SavePath = "/default"
+	edited := base.clone()
+	edited.AutoSync = true
+	edited.Network.Timeouts.ConnectSeconds = 20
+	edited.GameModes = []string{"a", "b"}
+
+	mergeEdits(file, base, edited)
+
+	if file.NebulaURL != "http://file" || file.SavePath != "" {
+		t.Errorf("没有修改的覆盖值被写入: url=%q save_path=%q", file.NebulaURL, file.SavePath)
+	}
+	if !file.AutoSync || file.Network.Timeouts.ConnectSeconds != 20 || !reflect.DeepEqual(file.GameModes, []string{"a", "b"}) {
+		t.Errorf("修改没有写入: %+v", file)
+	}
+}
+
+func TestEffectiveRejectsInvalidOverrides(t *testing.T) {
+	t.Setenv("BG3SYNC_NEBULA_URL", "http://env")
+	opts, err := parseOptions([]string{"-auto-sync=false", "-retention-keep-last", "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := &Config{NebulaURL: "http://file", AutoSync: true, SavePath: "/saves"}
+
+	config, err := opts.effective(file)
+	if err == nil {
+		t.Fatal("无效的参数应该报错")
+	}
+	// 有效的覆盖值也不应用，不能只应用一部分
+	if config.NebulaURL != "http://file" || !config.AutoSync {
+		t.Errorf("覆盖值有误时应该只使用配置文件: url=%q auto_sync=%v", config.NebulaURL, config.AutoSync)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// BG3 的存档目录结构: PlayerProfiles/<玩家档案>/Savegames/Story
+
+// 游戏默认的玩家档案
+const defaultPlayerProfile = "Public"
+
+// playerProfilesRoot PlayerProfiles 目录，位于存档目录上三级
+func playerProfilesRoot(savePath string) string {
+	return filepath.Dir(filepath.Dir(filepath.Dir(savePath)))
+}
+
+// playerProfileOf 存档目录所属的玩家档案名
+func playerProfileOf(savePath string) string {
+	return filepath.Base(filepath.Dir(filepath.Dir(savePath)))
+}
+
+// playerProfileSavePath 与 savePath 同级的另一个玩家档案的存档目录
+func playerProfileSavePath(savePath, profile string) string {
+	if profile == "" || profile == playerProfileOf(savePath) {
+		return savePath
+	}
+	return filepath.Join(playerProfilesRoot(savePath), profile, "Savegames", "Story")
+}
+
+// detectPlayerProfiles 列出 PlayerProfiles 下所有带存档目录的玩家档案
+func detectPlayerProfiles(savePath string) []string {
+	current := playerProfileOf(savePath)
+	profiles := []string{current}
+
+	entries, err := os.ReadDir(playerProfilesRoot(savePath))
+	if err != nil {
+		return profiles
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == current {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(playerProfilesRoot(savePath), entry.Name(), "Savegames"))
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		profiles = append(profiles, entry.Name())
+	}
+
+	sort.Strings(profiles[1:])
+	return profiles
+}
+
+// watchedSavePaths 需要监听的所有存档目录，未选择玩家档案时只监听存档路径
+func (c *Client) watchedSavePaths() []string {
+	cfg := c.config.Load()
+	if len(cfg.PlayerProfiles) == 0 {
+		return []string{cfg.SavePath}
+	}
+
+	var paths []string
+	for _, profile := range cfg.PlayerProfiles {
+		paths = append(paths, playerProfileSavePath(cfg.SavePath, profile))
+	}
+	return paths
+}
+
+// isWatchedSavePath 目录是否是监听的存档目录之一
+func (c *Client) isWatchedSavePath(dir string) bool {
+	for _, path := range c.watchedSavePaths() {
+		if filepath.Clean(dir) == filepath.Clean(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// saveDirFor 云端存档应恢复到的存档目录，按上传时的玩家档案区分
+func (c *Client) saveDirFor(save *SaveGame) string {
+	return playerProfileSavePath(c.config.Load().SavePath, save.Profile)
+}
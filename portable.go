@@ -0,0 +1,97 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// 便携模式：程序旁有标记文件或使用 -portable 参数时，配置、日志、备份和缓存都保存在程序旁的数据目录，
+// 可以把程序放在 U 盘上在多台电脑之间使用
+const (
+	portableMarker  = "bg3sync.portable"
+	portableDataDir = "bg3sync-data"
+)
+
+// portable 是否处于便携模式
+var portable bool
+
+// executableDir 程序所在目录
+func executableDir() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return filepath.Dir(exe), nil
+}
+
+// setupDataDir 确定数据目录：指定的目录优先，其次是便携模式，否则使用默认位置
+func setupDataDir(opts *Options) {
+	if opts.DataDir != "" {
+		dataDir = opts.DataDir
+		return
+	}
+
+	dir, err := executableDir()
+	if err != nil {
+		if opts.Portable {
+			log.Printf("⚠️  无法确定程序所在目录，不使用便携模式: %v\n", err)
+		}
+		return
+	}
+
+	if !opts.Portable {
+		if _, err := os.Stat(filepath.Join(dir, portableMarker)); err != nil {
+			return
+		}
+	}
+
+	portable = true
+	dataDir = filepath.Join(dir, portableDataDir)
+}
+
+// portableDeviceID 便携模式下多台电脑共用一个配置文件，使用按主机名保存的本机设备ID，
+// 否则各台电脑上传的存档会显示为同一设备。本机还没有设备ID时为空，启动时会生成
+func portableDeviceID(config *Config) {
+	if !portable {
+		return
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		log.Printf("⚠️  无法获取主机名，使用配置中的设备ID: %v\n", err)
+		return
+	}
+	config.DeviceID = config.DeviceIDs[host]
+}
+
+// setDeviceID 保存本机的设备ID，便携模式下按主机名保存
+func (cfg *Config) setDeviceID(id string) {
+	host, err := os.Hostname()
+	if !portable || err != nil {
+		cfg.DeviceID = id
+		return
+	}
+	if cfg.DeviceIDs == nil {
+		cfg.DeviceIDs = map[string]string{}
+	}
+	cfg.DeviceIDs[host] = id
+}
+
+// portableSavePath 便携模式下换了电脑时，配置中的存档路径可能不存在，改用本机的默认路径
+func portableSavePath(config *Config) {
+	if !portable {
+		return
+	}
+	if _, err := os.Stat(config.SavePath); err == nil {
+		return
+	}
+
+	savePath := gameByID(config.Game).DefaultSavePath()
+	if savePath != config.SavePath {
+		log.Printf("存档路径 %s 不存在，使用本机默认路径: %s\n", config.SavePath, savePath)
+		config.SavePath = savePath
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+// Process 系统中的一个进程
+type Process struct {
+	PID  int
+	Name string   // 进程名
+	Exe  string   // 可执行文件路径，取不到时为空
+	Args []string // 命令行参数，取不到时为空
+}
+
+// ProcessEvent 进程启动或退出
+type ProcessEvent struct {
+	Process
+	Started bool
+}
+
+// ProcessWatcher 监视匹配的进程，进程启动或退出时发送事件，ctx 取消后关闭通道
+type ProcessWatcher interface {
+	Watch(ctx context.Context, match func(Process) bool) <-chan ProcessEvent
+}
+
+// 进程列表的轮询间隔
+const processPollInterval = 2 * time.Second
+
+// pollingWatcher 定时列出进程并比较前后两次的结果。
+// 各系统订阅进程事件的接口都需要管理员权限，因此统一使用轮询，列出进程的方式由各系统实现
+type pollingWatcher struct {
+	list     func() ([]Process, error)
+	interval time.Duration
+}
+
+// newProcessWatcher 当前系统的进程监视器
+func newProcessWatcher() ProcessWatcher {
+	return &pollingWatcher{list: listProcesses, interval: processPollInterval}
+}
+
+func (w *pollingWatcher) Watch(ctx context.Context, match func(Process) bool) <-chan ProcessEvent {
+	events := make(chan ProcessEvent)
+
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		running := make(map[int]Process)
+		send := func(p Process, started bool) bool {
+			select {
+			case events <- ProcessEvent{Process: p, Started: started}:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			procs, err := w.list()
+			if err != nil {
+				log.Printf("列出进程失败: %v\n", err)
+			} else {
+				seen := make(map[int]bool)
+				for _, p := range procs {
+					if !match(p) {
+						continue
+					}
+					seen[p.PID] = true
+					if _, ok := running[p.PID]; !ok {
+						running[p.PID] = p
+						if !send(p, true) {
+							return
+						}
+					}
+				}
+				for pid, p := range running {
+					if !seen[pid] {
+						delete(running, pid)
+						if !send(p, false) {
+							return
+						}
+					}
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events
+}
+
+// matchProcess 按进程名、可执行文件名或第一个命令行参数匹配（不区分大小写）。
+// 通过 Wine/Proton 运行的游戏，可执行文件是 wine 的加载器，游戏的 .exe 路径在命令行中
+func matchProcess(p Process, names []string) bool {
+	candidates := []string{p.Name, baseName(p.Exe)}
+	if len(p.Args) > 0 {
+		candidates = append(candidates, baseName(p.Args[0]))
+	}
+
+	for _, name := range names {
+		for _, candidate := range candidates {
+			if candidate != "" && strings.EqualFold(candidate, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// baseName 文件名，同时支持 / 和 Windows 的 \ 分隔符
+func baseName(path string) string {
+	if i := strings.LastIndexAny(path, `/\`); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
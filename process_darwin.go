@@ -0,0 +1,41 @@
+//go:build darwin
+
+package main
+
+import (
+	"bytes"
+
+	"golang.org/x/sys/unix"
+)
+
+// listProcesses 通过 sysctl 列出进程 (macOS)。
+// p_comm 最长 16 个字符，因此同时通过 kern.procargs2 读取可执行文件路径
+func listProcesses() ([]Process, error) {
+	kprocs, err := unix.SysctlKinfoProcSlice("kern.proc.all")
+	if err != nil {
+		return nil, err
+	}
+
+	procs := make([]Process, 0, len(kprocs))
+	for _, kp := range kprocs {
+		pid := int(kp.Proc.P_pid)
+		p := Process{PID: pid, Name: unix.ByteSliceToString(kp.Proc.P_comm[:])}
+		// 其他用户的进程没有权限读取，忽略
+		p.Exe = processExecutable(pid)
+		procs = append(procs, p)
+	}
+	return procs, nil
+}
+
+// processExecutable 读取进程的可执行文件路径，kern.procargs2 的格式为 argc (int32) 后跟以 NUL 结尾的路径
+func processExecutable(pid int) string {
+	raw, err := unix.SysctlRaw("kern.procargs2", pid)
+	if err != nil || len(raw) <= 4 {
+		return ""
+	}
+	raw = raw[4:]
+	if i := bytes.IndexByte(raw, 0); i >= 0 {
+		raw = raw[:i]
+	}
+	return string(raw)
+}
@@ -0,0 +1,47 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// listProcesses 扫描 /proc 列出进程 (Linux)。
+// comm 最长 15 个字符，因此同时读取可执行文件路径和命令行（Proton 运行的游戏需要命令行匹配）
+func listProcesses() ([]Process, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+
+	var procs []Process
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+
+		dir := filepath.Join("/proc", entry.Name())
+		comm, err := os.ReadFile(filepath.Join(dir, "comm"))
+		if err != nil {
+			// 进程已退出
+			continue
+		}
+
+		p := Process{PID: pid, Name: strings.TrimSpace(string(comm))}
+		// 其他用户的进程没有权限读取 exe，忽略
+		p.Exe, _ = os.Readlink(filepath.Join(dir, "exe"))
+		if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+			for _, arg := range bytes.Split(bytes.TrimRight(cmdline, "\x00"), []byte{0}) {
+				p.Args = append(p.Args, string(arg))
+			}
+		}
+		procs = append(procs, p)
+	}
+	return procs, nil
+}
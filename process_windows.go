@@ -9,11 +9,11 @@ import (
 	"golang.org/x/sys/windows"
 )
 
-// 检查进程是否运行 (Windows)
-func isProcessRunning(processName string) bool {
+// listProcesses 通过 Toolhelp 快照列出进程 (Windows)
+func listProcesses() ([]Process, error) {
 	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
 	if err != nil {
-		return false
+		return nil, err
 	}
 	defer windows.CloseHandle(snapshot)
 
@@ -21,19 +21,20 @@ func isProcessRunning(processName string) bool {
 	procEntry.Size = uint32(unsafe.Sizeof(procEntry))
 
 	if err := windows.Process32First(snapshot, &procEntry); err != nil {
-		return false
+		return nil, err
 	}
 
+	var procs []Process
 	for {
-		exeName := windows.UTF16ToString(procEntry.ExeFile[:])
-		if exeName == processName {
-			return true
-		}
+		procs = append(procs, Process{
+			PID:  int(procEntry.ProcessID),
+			Name: windows.UTF16ToString(procEntry.ExeFile[:]),
+		})
 
 		if err := windows.Process32Next(snapshot, &procEntry); err != nil {
 			break
 		}
 	}
 
-	return false
+	return procs, nil
 }
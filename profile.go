@@ -0,0 +1,366 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// 玩家档案快照作为一个特殊的战役上传
+const (
+	profileGameMode = "Profile"
+	profileFolder   = "PlayerProfile__" + profileGameMode
+)
+
+// ProfileItem 可同步的玩家档案内容，Patterns 匹配 PlayerProfiles/Public 下的文件或文件夹名（不区分大小写）
+type ProfileItem struct {
+	Key      string
+	Name     string
+	Patterns []string
+}
+
+var profileItems = []ProfileItem{
+	{Key: "profile", Name: "玩家档案 (profile8.lsf)", Patterns: []string{"profile*.lsf"}},
+	{Key: "settings", Name: "游戏设置 (playerprofile)", Patterns: []string{"playerprofile*", "*settings*.lsx", "*settings*.lsf"}},
+	{Key: "hotbar", Name: "快捷栏布局", Patterns: []string{"*hotbar*"}},
+	{Key: "presets", Name: "角色预设", Patterns: []string{"*presets*"}},
+}
+
+// ProfileSyncConfig 玩家档案同步设置
+type ProfileSyncConfig struct {
+	Enabled bool     `json:"enabled"`
+	Items   []string `json:"items"` // 同步的 ProfileItem.Key
+}
+
+func defaultProfileSync() ProfileSyncConfig {
+	cfg := ProfileSyncConfig{}
+	for _, item := range profileItems {
+		cfg.Items = append(cfg.Items, item.Key)
+	}
+	return cfg
+}
+
+// Has 是否同步该项
+func (p ProfileSyncConfig) Has(key string) bool {
+	for _, item := range p.Items {
+		if item == key {
+			return true
+		}
+	}
+	return false
+}
+
+// profileDir 玩家档案目录 (PlayerProfiles/Public)，位于存档目录上两级
+func profileDir(savePath string) string {
+	return filepath.Dir(filepath.Dir(savePath))
+}
+
+// isProfileSave 云端存档是否是玩家档案快照
+func isProfileSave(save *SaveGame) bool {
+	return save.GameMode == profileGameMode || save.CampaignName() == profileFolder
+}
+
+// matchProfileItem 返回文件或文件夹名匹配的同步项
+func matchProfileItem(name string, cfg ProfileSyncConfig) bool {
+	lower := strings.ToLower(name)
+	// 存档和模组设置单独同步
+	if lower == "savegames" || lower == "modsettings.lsx" {
+		return false
+	}
+
+	for _, item := range profileItems {
+		if !cfg.Has(item.Key) {
+			continue
+		}
+		for _, pattern := range item.Patterns {
+			if ok, _ := filepath.Match(pattern, lower); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// collectProfileFiles 列出需要同步的文件，返回以 / 分隔的相对路径，按名称排序
+func collectProfileFiles(dir string, cfg ProfileSyncConfig) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取玩家档案目录失败: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !matchProfileItem(entry.Name(), cfg) {
+			continue
+		}
+
+		root := filepath.Join(dir, entry.Name())
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// zipProfileFiles 打包玩家档案文件，同时返回内容哈希用于判断是否有变化
+func zipProfileFiles(dir string, files []string) ([]byte, string, error) {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	hash := sha256.New()
+
+	for _, name := range files {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return nil, "", err
+		}
+
+		w, err := writer.Create(name)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, "", err
+		}
+
+		fmt.Fprintf(hash, "%s\x00%d\x00", name, len(data))
+		hash.Write(data)
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// 每个服务器和玩家档案最近一次上传的内容哈希，保存在数据目录中，重启后不会重复上传
+func getProfileHashesPath() string {
+	return filepath.Join(getAppDataDir(), "profile_hashes.json")
+}
+
+func loadProfileHashes() map[string]string {
+	hashes := make(map[string]string)
+	if data, err := os.ReadFile(getProfileHashesPath()); err == nil {
+		if err := json.Unmarshal(data, &hashes); err != nil {
+			log.Printf("读取玩家档案哈希失败: %v\n", err)
+		}
+	}
+	return hashes
+}
+
+func saveProfileHashes(hashes map[string]string) {
+	data, err := json.MarshalIndent(hashes, "", "  ")
+	if err == nil {
+		err = os.WriteFile(getProfileHashesPath(), data, 0644)
+	}
+	if err != nil {
+		log.Printf("保存玩家档案哈希失败: %v\n", err)
+	}
+}
+
+// syncProfile 开启玩家档案同步时，在存档目录所属玩家档案的内容有变化后上传新版本。
+// 打包和上传可能较慢，存档上传完成后在后台调用
+func (c *Client) syncProfile(savePath string) {
+	cfg := c.config.Load().ProfileSync
+	if !cfg.Enabled {
+		return
+	}
+
+	c.profileLock.Lock()
+	defer c.profileLock.Unlock()
+
+	dir := profileDir(savePath)
+	profile := playerProfileOf(savePath)
+	files, err := collectProfileFiles(dir, cfg)
+	if err != nil {
+		log.Printf("同步玩家档案失败: %v\n", err)
+		return
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	zipData, hash, err := zipProfileFiles(dir, files)
+	if err != nil {
+		log.Printf("打包玩家档案失败: %v\n", err)
+		return
+	}
+	api := c.api.Load()
+	key := api.baseURL + "|" + profile
+	hashes := loadProfileHashes()
+	if hash == hashes[key] {
+		return
+	}
+
+	ctx, progress, end := c.beginTransfer(context.Background(), "上传", "玩家档案")
+	defer end()
+
+	info := &SaveInfo{Game: c.game().ID, Folder: profileFolder, GameMode: profileGameMode, SaveName: "玩家档案", Profile: profile}
+	if _, err := api.UploadSave(ctx, profileFolder+".zip", zipData, info, progress); err != nil {
+		log.Printf("上传玩家档案失败: %v\n", err)
+		return
+	}
+
+	hashes[key] = hash
+	saveProfileHashes(hashes)
+	log.Printf("已备份玩家档案 %s (%d 个文件, %s)\n", profile, len(files), formatSize(int64(len(zipData))))
+}
+
+// latestGameSave 获取最新的游戏存档，跳过玩家档案快照
+func (c *Client) latestGameSave(ctx context.Context) (*SaveGame, error) {
+	listResp, err := c.api.Load().ListSaves(ctx, ListSavesOptions{Limit: 20})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, save := range listResp.Saves {
+		if !isProfileSave(save) && !c.isOtherGameSave(save) {
+			return save, nil
+		}
+	}
+	return nil, fmt.Errorf("没有找到存档")
+}
+
+// restoreProfile 下载玩家档案快照，确认后覆盖本地文件（覆盖前备份）
+func (c *Client) restoreProfile(save *SaveGame) {
+	c.statusBar.Set("正在下载玩家档案...")
+
+	go func() {
+		ctx, progress, end := c.beginTransfer(context.Background(), "下载", "玩家档案")
+		data, err := c.downloadVerified(ctx, save, progress)
+		end()
+		if errors.Is(err, context.Canceled) {
+			c.statusBar.Set("已取消下载")
+			return
+		}
+		if err != nil {
+			fyne.Do(func() {
+				c.statusBar.Set(fmt.Sprintf("下载失败: %v", err))
+				dialog.ShowError(err, c.mainWin)
+			})
+			return
+		}
+
+		reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			fyne.Do(func() {
+				dialog.ShowError(fmt.Errorf("读取压缩包失败: %w", err), c.mainWin)
+			})
+			return
+		}
+		var names []string
+		for _, file := range reader.File {
+			if !file.FileInfo().IsDir() {
+				names = append(names, file.Name)
+			}
+		}
+
+		fyne.Do(func() {
+			c.statusBar.Set("就绪")
+			msg := fmt.Sprintf("将用 %s 的玩家档案覆盖以下本地文件（覆盖前会自动备份）:\n\n%s",
+				save.Timestamp.Format("2006-01-02 15:04:05"), strings.Join(names, "\n"))
+			dialog.ShowConfirm("恢复玩家档案", msg, func(ok bool) {
+				if ok {
+					go c.performProfileRestore(profileDir(c.saveDirFor(save)), data, names)
+				}
+			}, c.mainWin)
+		})
+	}()
+}
+
+func (c *Client) performProfileRestore(dir string, data []byte, names []string) {
+	if err := backupProfileFiles(dir, names); err != nil {
+		fyne.Do(func() {
+			c.statusBar.Set(fmt.Sprintf("备份玩家档案失败: %v", err))
+			dialog.ShowError(err, c.mainWin)
+		})
+		return
+	}
+
+	if err := unzipToFolder(data, dir); err != nil {
+		fyne.Do(func() {
+			c.statusBar.Set(fmt.Sprintf("恢复玩家档案失败: %v", err))
+			dialog.ShowError(err, c.mainWin)
+		})
+		return
+	}
+
+	log.Printf("已恢复玩家档案 (%d 个文件)\n", len(names))
+	fyne.Do(func() {
+		c.statusBar.Set("玩家档案已恢复")
+		dialog.ShowInformation("成功", "玩家档案已恢复，重新启动游戏后生效", c.mainWin)
+	})
+}
+
+// backupProfileFiles 将即将被覆盖的本地文件打包到本地备份目录
+func backupProfileFiles(dir string, names []string) error {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	count := 0
+
+	for _, name := range names {
+		f, err := os.Open(filepath.Join(dir, filepath.FromSlash(name)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		w, err := writer.Create(name)
+		if err == nil {
+			_, err = io.Copy(w, f)
+		}
+		f.Close()
+		if err != nil {
+			return err
+		}
+		count++
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+
+	backupDir := filepath.Join(getBackupsDir(), profileFolder)
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return fmt.Errorf("创建备份目录失败: %w", err)
+	}
+	backupPath := filepath.Join(backupDir, time.Now().Format(localBackupTimeFormat)+".zip")
+	if err := os.WriteFile(backupPath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("写入备份失败: %w", err)
+	}
+
+	log.Printf("已备份玩家档案: %s\n", backupPath)
+	return nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"github.com/fsnotify/fsnotify"
+)
+
+// 修改设置（设置窗口、切换同步配置或直接编辑 config.json）后立即生效，不需要重启
+
+// updateConfig 修改配置、保存到配置文件并立即生效，需要在主线程调用。
+// edit 修改的是当前配置的副本，只有其中修改过的配置项会写入配置文件，
+// 环境变量和命令行参数的覆盖值不会因为保存设置而写入 config.json
+func (c *Client) updateConfig(edit func(cfg *Config)) error {
+	current := c.config.Load()
+	base := current.clone()
+	edited := current.clone()
+	edit(edited)
+
+	return c.updateFileConfig(func(file *Config) {
+		deviceID := file.DeviceID
+		mergeEdits(file, base, edited)
+		// 便携模式下修改的是本机的设备ID
+		if edited.DeviceID != base.DeviceID {
+			file.DeviceID = deviceID
+			file.setDeviceID(edited.DeviceID)
+		}
+		file.storeActiveProfile()
+	})
+}
+
+// updateFileConfig 直接修改配置文件中的内容（如同步配置的切换和删除），保存后立即生效，需要在主线程调用
+func (c *Client) updateFileConfig(edit func(file *Config)) error {
+	file := c.fileConfig.clone()
+	edit(file)
+	if err := saveConfig(file); err != nil {
+		return err
+	}
+
+	// 覆盖值有误时启动时已经提示过，和启动时一样只使用配置文件的内容
+	next, _ := c.options.effective(file)
+	c.fileConfig = file
+	c.setConfig(next)
+	return nil
+}
+
+// setConfig 替换当前配置并应用变化，需要在主线程调用。
+// 后台协程随时可能读取配置，因此整体替换指针而不是修改原配置
+func (c *Client) setConfig(next *Config) {
+	old := c.config.Swap(next)
+	c.applyConfig(old)
+}
+
+// applyConfig 比较新旧配置，重新创建受影响的部分。需要在主线程调用
+func (c *Client) applyConfig(old *Config) {
+	cfg := c.config.Load()
+
+	if cfg.NebulaURL != old.NebulaURL || cfg.DeviceID != old.DeviceID || cfg.Network != old.Network {
+		log.Printf("服务器或网络设置已修改，重新连接: %s\n", cfg.NebulaURL)
+		c.api.Store(c.buildAPI())
+		c.applyBandwidthLimits()
+
+		c.mirrorLock.Lock()
+		c.mirrorAPIs = nil
+		c.mirrorLock.Unlock()
+
+		c.restartHealthMonitor()
+		if cfg.NebulaURL != old.NebulaURL {
+			c.refreshSavesList()
+		}
+	} else if cfg.Bandwidth != old.Bandwidth {
+		c.applyBandwidthLimits()
+	}
+
+	if cfg.Game != old.Game ||
+		cfg.SavePath != old.SavePath ||
+		!slices.Equal(cfg.PlayerProfiles, old.PlayerProfiles) ||
+		!slices.Equal(cfg.GameModes, old.GameModes) {
+		log.Printf("存档目录设置已修改，重新监听\n")
+		go func() {
+			if err := c.StartWatching(); err != nil {
+				log.Printf("启动文件监听失败: %v\n", err)
+				c.statusBar.Set(fmt.Sprintf("启动文件监听失败: %v", err))
+			}
+		}()
+	}
+
+	if cfg.CheckpointHotkey != old.CheckpointHotkey {
+		c.setupCheckpointHotkey()
+	}
+
+	if c.autoSyncCheck != nil && c.autoSyncCheck.Checked != cfg.AutoSync {
+		c.autoSyncCheck.SetChecked(cfg.AutoSync)
+	}
+}
+
+// restartHealthMonitor 停止正在运行的健康检查并重新开始
+func (c *Client) restartHealthMonitor() {
+	if c.healthLabel == nil {
+		return
+	}
+	if c.stopHealth != nil {
+		c.stopHealth()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.stopHealth = cancel
+	go c.monitorHealth(ctx, c.healthLabel)
+}
+
+// watchConfigFile 监听 config.json 的外部修改并重新加载。
+// 保存配置是先写临时文件再替换，因此监听所在目录而不是文件本身
+func (c *Client) watchConfigFile() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	path := getConfigPath()
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+		debouncer := NewDebouncer(500 * time.Millisecond)
+		defer debouncer.Stop()
+
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != filepath.Clean(path) ||
+					!(event.Has(fsnotify.Write) || event.Has(fsnotify.Create)) {
+					continue
+				}
+				debouncer.Do(c.reloadConfigFile)
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("配置文件监听错误: %v\n", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// reloadConfigFile 重新读取 config.json，仍然应用环境变量和命令行参数。
+// 内容与当前配置相同（如刚由本程序保存）时忽略，有误时保留当前配置
+func (c *Client) reloadConfigFile() {
+	data, err := os.ReadFile(getConfigPath())
+	if err != nil {
+		log.Printf("读取配置文件失败: %v\n", err)
+		return
+	}
+
+	file, err := parseConfig(data)
+	var next *Config
+	if err == nil {
+		// 覆盖值有误时启动时已经提示过，和启动时一样只使用配置文件的内容
+		next, _ = c.options.effective(file)
+		err = next.Validate()
+	}
+	if err != nil {
+		log.Printf("⚠️  配置文件有误，未重新加载: %v\n", err)
+		fyne.Do(func() {
+			c.statusBar.Set("配置文件有误，未重新加载")
+			dialog.ShowError(fmt.Errorf("配置文件有误，未重新加载:\n%w", err), c.mainWin)
+		})
+		return
+	}
+
+	fyne.Do(func() {
+		// 被覆盖的配置项在文件中的修改不影响当前配置，但之后保存时需要保留
+		c.fileConfig = file
+
+		current, _ := json.Marshal(c.config.Load())
+		updated, _ := json.Marshal(next)
+		if bytes.Equal(current, updated) {
+			return
+		}
+
+		log.Printf("配置文件已修改，重新加载\n")
+		c.setConfig(next)
+		c.statusBar.Set("已重新加载配置文件")
+	})
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+	"golang.org/x/image/webp"
+)
+
+// FileEntry 存档中的单个文件
+type FileEntry struct {
+	Name string // 相对路径，使用 / 分隔
+	Size int64
+	Hash string // SHA-256
+}
+
+// SaveSnapshot 恢复前对比用的存档快照（本地或云端）
+type SaveSnapshot struct {
+	Exists    bool
+	SaveName  string
+	Character string // 角色名，空表示未知
+	Time      time.Time
+	Playtime  int  // 秒，0 表示未知
+	Estimated bool // 存档中读取不到游戏时长，取自本机上传的对应云端版本
+	Device    string
+	Size      int64
+	Files     []FileEntry
+	Thumbnail image.Image
+}
+
+// snapshotFromArchive 解析云端存档压缩包
+func snapshotFromArchive(save *SaveGame, data []byte) (*SaveSnapshot, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("读取压缩包失败: %w", err)
+	}
+
+	snap := &SaveSnapshot{
+		Exists:    true,
+		SaveName:  save.SaveName,
+		Character: save.CharacterName,
+		Time:      save.Timestamp,
+		Playtime:  save.GameTime,
+		Device:    save.DeviceID,
+	}
+
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() || isSyncMeta(file.Name) {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 失败: %w", file.Name, err)
+		}
+
+		snap.addFile(strings.ReplaceAll(file.Name, "\\", "/"), content)
+
+		// 旧版本上传的存档没有记录角色名和游戏时长，从存档文件读取
+		if isSaveExt(filepath.Ext(file.Name)) && (snap.Character == "" || snap.Playtime == 0) {
+			if meta, err := readSaveMeta(bytes.NewReader(content), int64(len(content))); err == nil {
+				snap.fillMeta(meta)
+			}
+		}
+	}
+
+	snap.sortFiles()
+	return snap, nil
+}
+
+// snapshotFromFolder 解析本地存档文件夹，文件夹不存在时 Exists 为 false
+func snapshotFromFolder(folderPath string) (*SaveSnapshot, error) {
+	snap := &SaveSnapshot{}
+	if _, err := os.Stat(folderPath); os.IsNotExist(err) {
+		return snap, nil
+	}
+	snap.Exists = true
+
+	if info, err := readSaveInfo(folderPath); err == nil {
+		snap.SaveName = info.SaveName
+		snap.Time = info.ModTime
+		snap.fillMeta(&SaveMeta{LeaderName: info.LeaderName, GameTime: info.GameTime})
+	}
+
+	err := filepath.Walk(folderPath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		relPath, err := filepath.Rel(folderPath, filePath)
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		snap.addFile(filepath.ToSlash(relPath), content)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取本地存档失败: %w", err)
+	}
+
+	snap.sortFiles()
+	return snap, nil
+}
+
+// fillMeta 补充快照中缺少的角色名和游戏时长
+func (snap *SaveSnapshot) fillMeta(meta *SaveMeta) {
+	if snap.Character == "" {
+		snap.Character = meta.LeaderName
+	}
+	if snap.Playtime == 0 {
+		snap.Playtime = meta.GameTime
+	}
+}
+
+func (snap *SaveSnapshot) addFile(name string, content []byte) {
+	sum := sha256.Sum256(content)
+	snap.Files = append(snap.Files, FileEntry{
+		Name: name,
+		Size: int64(len(content)),
+		Hash: hex.EncodeToString(sum[:]),
+	})
+	snap.Size += int64(len(content))
+
+	ext := filepath.Ext(name)
+	if snap.Thumbnail == nil && isThumbnailExt(ext) {
+		decode := webp.Decode
+		if strings.EqualFold(ext, ".png") {
+			decode = png.Decode
+		}
+		if img, err := decode(bytes.NewReader(content)); err == nil {
+			snap.Thumbnail = img
+		}
+	}
+	if snap.SaveName == "" && isSaveExt(ext) {
+		snap.SaveName = strings.TrimSuffix(filepath.Base(name), ext)
+	}
+}
+
+func (snap *SaveSnapshot) sortFiles() {
+	sort.Slice(snap.Files, func(i, j int) bool {
+		return snap.Files[i].Name < snap.Files[j].Name
+	})
+}
+
+// fileDiff 本地与云端同名文件的对比
+type fileDiff struct {
+	Name  string
+	Local *FileEntry
+	Cloud *FileEntry
+}
+
+func (d fileDiff) status() string {
+	switch {
+	case d.Local == nil:
+		return "仅云端"
+	case d.Cloud == nil:
+		return "仅本地"
+	case d.Local.Hash == d.Cloud.Hash:
+		return "相同"
+	}
+	return "不同"
+}
+
+// diffFiles 按文件名合并本地和云端文件列表
+func diffFiles(local, cloud []FileEntry) []fileDiff {
+	index := make(map[string]*fileDiff)
+	var names []string
+
+	entry := func(name string) *fileDiff {
+		if d, ok := index[name]; ok {
+			return d
+		}
+		d := &fileDiff{Name: name}
+		index[name] = d
+		names = append(names, name)
+		return d
+	}
+
+	for i := range local {
+		entry(local[i].Name).Local = &local[i]
+	}
+	for i := range cloud {
+		entry(cloud[i].Name).Cloud = &cloud[i]
+	}
+
+	sort.Strings(names)
+	diffs := make([]fileDiff, len(names))
+	for i, name := range names {
+		diffs[i] = *index[name]
+	}
+	return diffs
+}
+
+// localRevision 在已加载的云端存档中查找本机上传的、与本地存档对应的版本
+func (c *Client) localRevision(campaign string, modTime time.Time) *SaveGame {
+	c.savesLock.Lock()
+	defer c.savesLock.Unlock()
+
+	var match *SaveGame
+	for _, save := range c.saves {
+		if save.CampaignName() != campaign || save.DeviceID != c.config.Load().DeviceID {
+			continue
+		}
+		if save.Timestamp.Before(modTime) {
+			continue
+		}
+		if match == nil || save.Timestamp.Before(match.Timestamp) {
+			match = save
+		}
+	}
+	return match
+}
+
+// showRestorePreview 显示本地与云端存档的对比，确认后恢复
+func (c *Client) showRestorePreview(save *SaveGame, data []byte, local, cloud *SaveSnapshot, mods *ModComparison) {
+	win := c.app.NewWindow("恢复预览")
+	win.Resize(fyne.NewSize(900, 650))
+
+	diffs := diffFiles(local.Files, cloud.Files)
+
+	// 提示信息
+	warning := widget.NewLabel("")
+	warning.Wrapping = fyne.TextWrapWord
+	switch {
+	case !local.Exists:
+		warning.SetText("本地没有该存档，将直接恢复云端版本")
+	case cloud.Time.Before(local.Time):
+		warning.SetText("⚠ 云端存档比本地存档更旧，恢复会丢失本地更新的进度!")
+		warning.Importance = widget.DangerImportance
+	case allFilesSame(diffs):
+		warning.SetText("本地存档与云端存档完全相同，无需恢复")
+		warning.Importance = widget.SuccessImportance
+	default:
+		warning.SetText("恢复后本地存档将被云端版本覆盖（覆盖前会自动备份）")
+		warning.Importance = widget.WarningImportance
+	}
+
+	columns := container.NewGridWithColumns(2,
+		snapshotCard("本地存档", local),
+		snapshotCard("云端存档", cloud),
+	)
+
+	fileList := widget.NewList(
+		func() int { return len(diffs) },
+		func() fyne.CanvasObject {
+			return container.NewGridWithColumns(4,
+				widget.NewLabel(""), widget.NewLabel(""), widget.NewLabel(""), widget.NewLabel(""),
+			)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			d := diffs[id]
+			cells := item.(*fyne.Container).Objects
+
+			cells[0].(*widget.Label).SetText(d.Name)
+			cells[1].(*widget.Label).SetText(formatFileEntry(d.Local))
+			cells[2].(*widget.Label).SetText(formatFileEntry(d.Cloud))
+
+			status := cells[3].(*widget.Label)
+			status.SetText(d.status())
+			if d.status() == "相同" {
+				status.Importance = widget.MediumImportance
+			} else {
+				status.Importance = widget.WarningImportance
+			}
+			status.Refresh()
+		},
+	)
+
+	header := container.NewGridWithColumns(4,
+		widget.NewLabelWithStyle("文件", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("本地 (大小 / 哈希)", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("云端 (大小 / 哈希)", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("状态", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+	)
+
+	cancelBtn := widget.NewButton("取消", func() {
+		win.Close()
+	})
+	// 模组差异
+	top := container.NewVBox(warning)
+	restoreMods := widget.NewCheck("同时恢复存档上传时的模组设置 (modsettings.lsx，覆盖前会自动备份)", nil)
+	if mods != nil && len(mods.Problems) > 0 {
+		modWarning := widget.NewLabel("⚠ 存档上传时的模组与本机不一致，加载存档可能失败:\n" + strings.Join(mods.Problems, "\n"))
+		modWarning.Wrapping = fyne.TextWrapWord
+		modWarning.Importance = widget.WarningImportance
+		top.Add(modWarning)
+		if mods.CanRestoreSettings() {
+			top.Add(restoreMods)
+		}
+	}
+	top.Add(columns)
+	top.Add(header)
+
+	restoreBtn := widget.NewButton("恢复此存档", func() {
+		win.Close()
+		var modSettings *ModSnapshot
+		if restoreMods.Checked {
+			modSettings = mods.Cloud
+		}
+		c.performRestore(save, data, modSettings)
+	})
+	restoreBtn.Importance = widget.DangerImportance
+
+	win.SetContent(container.NewPadded(container.NewBorder(
+		top,
+		container.NewHBox(layout.NewSpacer(), cancelBtn, restoreBtn),
+		nil, nil,
+		fileList,
+	)))
+	win.Show()
+}
+
+// snapshotCard 单侧存档信息卡片
+func snapshotCard(title string, snap *SaveSnapshot) fyne.CanvasObject {
+	if !snap.Exists {
+		return widget.NewCard(title, "", widget.NewLabel("不存在"))
+	}
+
+	var thumb fyne.CanvasObject
+	if snap.Thumbnail != nil {
+		img := canvas.NewImageFromImage(snap.Thumbnail)
+		img.FillMode = canvas.ImageFillContain
+		img.SetMinSize(fyne.NewSize(240, 135))
+		thumb = img
+	} else {
+		thumb = widget.NewLabel("（无截图）")
+	}
+
+	device := snap.Device
+	if device == "" {
+		device = "未知"
+	}
+	character := snap.Character
+	if character == "" {
+		character = "未知"
+	}
+
+	// 存档中读取不到游戏时长时，按本机最近上传的版本估算
+	playtime := formatPlaytime(snap.Playtime)
+	if snap.Estimated && snap.Playtime > 0 {
+		playtime = "约 " + playtime + "（按上传记录估算）"
+	}
+
+	return widget.NewCard(title, "", container.NewVBox(
+		thumb,
+		widget.NewForm(
+			widget.NewFormItem("存档名", widget.NewLabel(snap.SaveName)),
+			widget.NewFormItem("角色", widget.NewLabel(character)),
+			widget.NewFormItem("时间", widget.NewLabel(snap.Time.Format("2006-01-02 15:04:05"))),
+			widget.NewFormItem("游戏时长", widget.NewLabel(playtime)),
+			widget.NewFormItem("设备", widget.NewLabel(device)),
+			widget.NewFormItem("大小", widget.NewLabel(fmt.Sprintf("%s (%d 个文件)", formatSize(snap.Size), len(snap.Files)))),
+		),
+	))
+}
+
+func formatFileEntry(entry *FileEntry) string {
+	if entry == nil {
+		return "—"
+	}
+	return fmt.Sprintf("%s / %s", formatSize(entry.Size), entry.Hash[:8])
+}
+
+func allFilesSame(diffs []fileDiff) bool {
+	for _, d := range diffs {
+		if d.status() != "相同" {
+			return false
+		}
+	}
+	return len(diffs) > 0
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// RetentionPolicy 备份保留策略，按战役分别计算，固定的存档永不删除
+type RetentionPolicy struct {
+	AutoApply   bool `json:"auto_apply"`   // 上传后自动执行清理
+	KeepLast    int  `json:"keep_last"`    // 每个战役保留最近 N 个版本（至少 1）
+	HourlyHours int  `json:"hourly_hours"` // 最近 N 小时内每小时保留一个
+	DailyDays   int  `json:"daily_days"`   // 最近 N 天内每天保留一个
+	KeepWeekly  bool `json:"keep_weekly"`  // 更早的版本每周保留一个
+}
+
+func defaultRetentionPolicy() RetentionPolicy {
+	return RetentionPolicy{
+		KeepLast:    10,
+		HourlyHours: 24,
+		DailyDays:   30,
+		KeepWeekly:  true,
+	}
+}
+
+// 自动清理的最小间隔
+const autoRetentionInterval = time.Hour
+
+// retentionEntry 参与保留策略计算的备份
+type retentionEntry struct {
+	Campaign string
+	Time     time.Time
+	Pinned   bool
+}
+
+// expired 返回应删除条目的下标（升序）
+func (p RetentionPolicy) expired(entries []retentionEntry, now time.Time) []int {
+	byCampaign := make(map[string][]int)
+	for i, e := range entries {
+		byCampaign[e.Campaign] = append(byCampaign[e.Campaign], i)
+	}
+
+	keepLast := p.KeepLast
+	if keepLast < 1 {
+		keepLast = 1
+	}
+
+	var result []int
+	for _, indexes := range byCampaign {
+		sort.SliceStable(indexes, func(a, b int) bool {
+			return entries[indexes[a]].Time.After(entries[indexes[b]].Time)
+		})
+
+		// 从新到旧遍历，每个时间段只保留最新的一个
+		seen := make(map[string]bool)
+		for n, i := range indexes {
+			e := entries[i]
+			bucket := p.bucket(e.Time, now)
+
+			keep := e.Pinned || n < keepLast || (bucket != "" && !seen[bucket])
+			if bucket != "" {
+				seen[bucket] = true
+			}
+			if !keep {
+				result = append(result, i)
+			}
+		}
+	}
+
+	sort.Ints(result)
+	return result
+}
+
+// bucket 返回备份所属的保留时间段，空字符串表示不在任何保留范围内
+func (p RetentionPolicy) bucket(t, now time.Time) string {
+	age := now.Sub(t)
+	switch {
+	case p.HourlyHours > 0 && age < time.Duration(p.HourlyHours)*time.Hour:
+		return "h" + t.Format("2006010215")
+	case p.DailyDays > 0 && age < time.Duration(p.DailyDays)*24*time.Hour:
+		return "d" + t.Format("20060102")
+	case p.KeepWeekly:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("w%d-%02d", year, week)
+	}
+	return ""
+}
+
+// Describe 策略的文字说明
+func (p RetentionPolicy) Describe() string {
+	parts := []string{fmt.Sprintf("每个战役保留最近 %d 个", p.KeepLast)}
+	if p.HourlyHours > 0 {
+		parts = append(parts, fmt.Sprintf("%d 小时内每小时 1 个", p.HourlyHours))
+	}
+	if p.DailyDays > 0 {
+		parts = append(parts, fmt.Sprintf("%d 天内每天 1 个", p.DailyDays))
+	}
+	if p.KeepWeekly {
+		parts = append(parts, "更早的每周 1 个")
+	}
+	return strings.Join(parts, "，") + "，固定的存档永不删除"
+}
+
+// RetentionPlan 按保留策略需要删除的备份
+type RetentionPlan struct {
+	Cloud []*SaveGame
+	Local []*LocalBackup
+}
+
+// Empty 是否没有需要删除的备份
+func (plan *RetentionPlan) Empty() bool {
+	return len(plan.Cloud) == 0 && len(plan.Local) == 0
+}
+
+// planRetention 计算需要删除的云端和本地备份（不执行删除）
+func (c *Client) planRetention(ctx context.Context, policy RetentionPolicy) (*RetentionPlan, error) {
+	now := time.Now()
+	plan := &RetentionPlan{}
+
+	saves, err := c.api.Load().ListAllSaves(ctx, ListSavesOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取云端存档失败: %w", err)
+	}
+
+	cloudEntries := make([]retentionEntry, len(saves))
+	for i, save := range saves {
+		cloudEntries[i] = retentionEntry{
+			Campaign: save.CampaignName(),
+			Time:     save.Timestamp,
+			Pinned:   save.Pinned,
+		}
+	}
+	for _, i := range policy.expired(cloudEntries, now) {
+		plan.Cloud = append(plan.Cloud, saves[i])
+	}
+
+	backups, err := listLocalBackups()
+	if err != nil {
+		return nil, fmt.Errorf("读取本地备份失败: %w", err)
+	}
+
+	localEntries := make([]retentionEntry, len(backups))
+	for i, backup := range backups {
+		localEntries[i] = retentionEntry{
+			Campaign: backup.Campaign,
+			Time:     backup.Time,
+		}
+	}
+	for _, i := range policy.expired(localEntries, now) {
+		plan.Local = append(plan.Local, backups[i])
+	}
+
+	return plan, nil
+}
+
+// applyRetention 执行删除，单个失败不影响其余，返回删除数量和最后一个错误
+func (c *Client) applyRetention(ctx context.Context, plan *RetentionPlan) (int, error) {
+	var lastErr error
+	deleted := 0
+
+	for _, save := range plan.Cloud {
+		if err := c.deleteCloudSave(ctx, save); err != nil {
+			if errors.Is(err, ErrPinned) {
+				log.Printf("跳过已固定的存档: %s\n", save.ID)
+				continue
+			}
+			log.Printf("清理云端存档失败 %s: %v\n", save.ID, err)
+			lastErr = err
+			continue
+		}
+		log.Printf("已清理云端存档: %s (%s)\n", save.FileName, save.Timestamp.Format("2006-01-02 15:04:05"))
+		deleted++
+	}
+
+	for _, backup := range plan.Local {
+		if err := os.Remove(backup.Path); err != nil {
+			log.Printf("清理本地备份失败 %s: %v\n", backup.Path, err)
+			lastErr = err
+			continue
+		}
+		log.Printf("已清理本地备份: %s\n", backup.Path)
+		deleted++
+	}
+
+	return deleted, lastErr
+}
+
+// maybeAutoRetention 上传成功后按策略自动清理，最多每小时执行一次
+func (c *Client) maybeAutoRetention() {
+	policy := c.config.Load().Retention
+	if !policy.AutoApply {
+		return
+	}
+
+	c.retentionLock.Lock()
+	if time.Since(c.lastRetentionRun) < autoRetentionInterval {
+		c.retentionLock.Unlock()
+		return
+	}
+	c.lastRetentionRun = time.Now()
+	c.retentionLock.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		plan, err := c.planRetention(ctx, policy)
+		if err != nil {
+			log.Printf("计算清理计划失败: %v\n", err)
+			return
+		}
+		if plan.Empty() {
+			return
+		}
+
+		deleted, err := c.applyRetention(ctx, plan)
+		if err != nil {
+			log.Printf("自动清理部分失败: %v\n", err)
+		}
+		log.Printf("自动清理完成，已删除 %d 个备份\n", deleted)
+	}()
+}
+
+// showRetention 保留策略设置、预览与执行
+func (c *Client) showRetention() {
+	win := c.app.NewWindow("备份清理")
+	win.Resize(fyne.NewSize(600, 500))
+
+	policy := c.config.Load().Retention
+
+	keepLast := widget.NewEntry()
+	keepLast.SetText(strconv.Itoa(policy.KeepLast))
+
+	hourlyHours := widget.NewEntry()
+	hourlyHours.SetText(strconv.Itoa(policy.HourlyHours))
+
+	dailyDays := widget.NewEntry()
+	dailyDays.SetText(strconv.Itoa(policy.DailyDays))
+
+	keepWeekly := widget.NewCheck("更早的版本每周保留一个", nil)
+	keepWeekly.SetChecked(policy.KeepWeekly)
+
+	autoApply := widget.NewCheck("上传后自动清理", nil)
+	autoApply.SetChecked(policy.AutoApply)
+
+	// 从输入框读取策略
+	readPolicy := func() (RetentionPolicy, error) {
+		var p RetentionPolicy
+		var err error
+		if p.KeepLast, err = strconv.Atoi(strings.TrimSpace(keepLast.Text)); err != nil || p.KeepLast < 1 {
+			return p, fmt.Errorf("保留最近版本数必须是大于 0 的整数")
+		}
+		if p.HourlyHours, err = strconv.Atoi(strings.TrimSpace(hourlyHours.Text)); err != nil || p.HourlyHours < 0 {
+			return p, fmt.Errorf("按小时保留的时长必须是非负整数")
+		}
+		if p.DailyDays, err = strconv.Atoi(strings.TrimSpace(dailyDays.Text)); err != nil || p.DailyDays < 0 {
+			return p, fmt.Errorf("按天保留的天数必须是非负整数")
+		}
+		p.KeepWeekly = keepWeekly.Checked
+		p.AutoApply = autoApply.Checked
+		return p, nil
+	}
+
+	// 预览结果
+	var plan *RetentionPlan
+	var lines []string
+	summary := widget.NewLabel("点击\"预览\"查看将被删除的备份")
+	preview := widget.NewList(
+		func() int { return len(lines) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(lines[id])
+		},
+	)
+
+	var applyBtn *widget.Button
+
+	saveBtn := widget.NewButton("保存策略", func() {
+		p, err := readPolicy()
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		if err := c.updateConfig(func(cfg *Config) { cfg.Retention = p }); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		summary.SetText("已保存: " + p.Describe())
+	})
+
+	previewBtn := widget.NewButton("预览", func() {
+		p, err := readPolicy()
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+
+		summary.SetText("正在计算...")
+		applyBtn.Disable()
+
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+
+			result, err := c.planRetention(ctx, p)
+			fyne.Do(func() {
+				if err != nil {
+					summary.SetText(fmt.Sprintf("计算失败: %v", err))
+					return
+				}
+
+				plan = result
+				lines = nil
+				for _, save := range plan.Cloud {
+					lines = append(lines, fmt.Sprintf("云端: %s - %s (%s)",
+						save.Timestamp.Format("2006-01-02 15:04:05"),
+						save.CampaignName(),
+						formatSize(save.FileSize),
+					))
+				}
+				for _, backup := range plan.Local {
+					lines = append(lines, fmt.Sprintf("本地: %s - %s (%s)",
+						backup.Time.Format("2006-01-02 15:04:05"),
+						backup.Campaign,
+						formatSize(backup.Size),
+					))
+				}
+				preview.Refresh()
+
+				summary.SetText(fmt.Sprintf("将删除 %d 个云端存档、%d 个本地备份",
+					len(plan.Cloud), len(plan.Local)))
+				if !plan.Empty() {
+					applyBtn.Enable()
+				}
+			})
+		}()
+	})
+
+	applyBtn = widget.NewButton("执行清理", func() {
+		if plan == nil || plan.Empty() {
+			return
+		}
+
+		warning := "此操作不可恢复!"
+		if c.config.Load().SoftDelete {
+			warning = "云端存档将移入回收站，本地备份将直接删除"
+		}
+
+		dialog.ShowConfirm(
+			"确认清理",
+			fmt.Sprintf("确定删除 %d 个云端存档和 %d 个本地备份?\n\n%s", len(plan.Cloud), len(plan.Local), warning),
+			func(ok bool) {
+				if !ok {
+					return
+				}
+
+				toApply := plan
+				applyBtn.Disable()
+				summary.SetText("正在清理...")
+
+				go func() {
+					ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+					defer cancel()
+
+					deleted, err := c.applyRetention(ctx, toApply)
+					fyne.Do(func() {
+						plan = nil
+						lines = nil
+						preview.Refresh()
+
+						if err != nil {
+							summary.SetText(fmt.Sprintf("已删除 %d 个，部分失败: %v", deleted, err))
+						} else {
+							summary.SetText(fmt.Sprintf("清理完成，已删除 %d 个备份", deleted))
+						}
+						c.refreshSavesList()
+					})
+				}()
+			},
+			win,
+		)
+	})
+	applyBtn.Disable()
+
+	form := container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem("保留最近版本数", keepLast),
+			widget.NewFormItem("按小时保留 (小时)", hourlyHours),
+			widget.NewFormItem("按天保留 (天)", dailyDays),
+		),
+		keepWeekly,
+		autoApply,
+		container.NewHBox(saveBtn, previewBtn, applyBtn),
+		summary,
+	)
+
+	win.SetContent(container.NewPadded(container.NewBorder(form, nil, nil, nil, preview)))
+	win.Show()
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRetentionBucket(t *testing.T) {
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	policy := defaultRetentionPolicy()
+
+	tests := []struct {
+		name   string
+		policy RetentionPolicy
+		age    time.Duration
+		want   string
+	}{
+		{"最近一小时", policy, 30 * time.Minute, "h2026061511"},
+		{"小时范围边界", policy, 24 * time.Hour, "d20260614"},
+		{"天范围内", policy, 29 * 24 * time.Hour, "d20260517"},
+		{"天范围边界", policy, 30 * 24 * time.Hour, "w2026-20"},
+		{"不按小时保留", RetentionPolicy{DailyDays: 1}, 30 * time.Minute, "d20260615"},
+		{"不按周保留", RetentionPolicy{HourlyHours: 1, DailyDays: 1}, 48 * time.Hour, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.bucket(now.Add(-tt.age), now); got != tt.want {
+				t.Errorf("bucket() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetentionExpired(t *testing.T) {
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+
+	tests := []struct {
+		name    string
+		policy  RetentionPolicy
+		entries []retentionEntry
+		want    []int
+	}{
+		{
+			name:   "保留最近 N 个",
+			policy: RetentionPolicy{KeepLast: 2},
+			entries: []retentionEntry{
+				{Campaign: "a", Time: ago(4 * time.Hour)},
+				{Campaign: "a", Time: ago(1 * time.Hour)},
+				{Campaign: "a", Time: ago(3 * time.Hour)},
+				{Campaign: "a", Time: ago(2 * time.Hour)},
+			},
+			want: []int{0, 2},
+		},
+		{
+			name:   "固定的存档不删除",
+			policy: RetentionPolicy{KeepLast: 1},
+			entries: []retentionEntry{
+				{Campaign: "a", Time: ago(3 * time.Hour), Pinned: true},
+				{Campaign: "a", Time: ago(2 * time.Hour)},
+				{Campaign: "a", Time: ago(1 * time.Hour)},
+			},
+			want: []int{1},
+		},
+		{
+			name:   "按战役分别计算",
+			policy: RetentionPolicy{KeepLast: 1},
+			entries: []retentionEntry{
+				{Campaign: "a", Time: ago(2 * time.Hour)},
+				{Campaign: "b", Time: ago(2 * time.Hour)},
+				{Campaign: "a", Time: ago(1 * time.Hour)},
+				{Campaign: "b", Time: ago(3 * time.Hour)},
+			},
+			want: []int{0, 3},
+		},
+		{
+			name:   "每小时保留最新一个",
+			policy: RetentionPolicy{KeepLast: 1, HourlyHours: 24},
+			entries: []retentionEntry{
+				{Campaign: "a", Time: ago(10 * time.Minute)},
+				{Campaign: "a", Time: ago(20 * time.Minute)},
+				{Campaign: "a", Time: ago(30 * time.Minute)},
+				{Campaign: "a", Time: ago(70 * time.Minute)},
+				{Campaign: "a", Time: ago(80 * time.Minute)},
+			},
+			want: []int{1, 2, 4},
+		},
+		{
+			name:   "超出保留范围",
+			policy: RetentionPolicy{KeepLast: 1, DailyDays: 1},
+			entries: []retentionEntry{
+				{Campaign: "a", Time: ago(1 * time.Hour)},
+				{Campaign: "a", Time: ago(48 * time.Hour)},
+			},
+			want: []int{1},
+		},
+		{
+			name:   "至少保留一个",
+			policy: RetentionPolicy{KeepLast: 0},
+			entries: []retentionEntry{
+				{Campaign: "a", Time: ago(400 * 24 * time.Hour)},
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.expired(tt.entries, now); !slices.Equal(got, tt.want) {
+				t.Errorf("expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SaveInfo 从本地存档文件夹解析出的信息
+type SaveInfo struct {
+	Game      string    // 所属游戏，见 GameDefinition.ID
+	Folder    string    // 文件夹名，即战役标识，如 <uuid>__HonourMode
+	GameMode  string    // 文件夹名后缀，如 HonourMode
+	Profile   string    // 所属玩家档案，如 Public
+	SaveName  string    // 存档文件名（不含扩展名）
+	SaveFile  string    // 存档文件名，如 <SaveName>.lsv
+	ModTime   time.Time // 存档文件最后修改时间
+	Size      int64     // 文件夹总大小
+	Thumbnail string    // 截图路径（.WebP 或 .png），没有则为空
+
+	LeaderName string // 从存档文件读取的角色名，读取失败时为空
+	GameTime   int    // 从存档文件读取的游戏时长（秒），读取失败时为 0
+
+	Mods *ModSnapshot // 上传时附带的模组设置，可为 nil
+}
+
+// readSaveInfo 解析本地存档文件夹
+func readSaveInfo(folderPath string) (*SaveInfo, error) {
+	entries, err := os.ReadDir(folderPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取存档文件夹失败: %w", err)
+	}
+
+	folderName := filepath.Base(folderPath)
+	info := &SaveInfo{
+		Folder:   folderName,
+		GameMode: gameModeOf(folderName),
+		Profile:  playerProfileOf(filepath.Dir(folderPath)),
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		fileInfo, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		info.Size += fileInfo.Size()
+
+		name := entry.Name()
+		ext := filepath.Ext(name)
+		switch {
+		case isSaveExt(ext):
+			info.SaveName = strings.TrimSuffix(name, ext)
+			info.SaveFile = name
+			info.ModTime = fileInfo.ModTime()
+		case isThumbnailExt(ext):
+			info.Thumbnail = filepath.Join(folderPath, name)
+		}
+	}
+
+	if info.SaveName == "" {
+		return nil, fmt.Errorf("存档文件夹中没有存档文件: %s", folderName)
+	}
+
+	// 角色名和游戏时长读取失败不影响上传和恢复
+	if meta, err := readSaveMetaFile(filepath.Join(folderPath, info.SaveFile)); err == nil {
+		info.LeaderName, info.GameTime = meta.LeaderName, meta.GameTime
+	} else {
+		log.Printf("读取存档信息失败 %s: %v\n", info.SaveFile, err)
+	}
+
+	return info, nil
+}
+
+// gameModeOf 从文件夹名解析游戏模式（<uuid>__HonourMode → HonourMode）
+func gameModeOf(folderName string) string {
+	if i := strings.LastIndex(folderName, "__"); i >= 0 {
+		return folderName[i+2:]
+	}
+	return ""
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// SaveMeta 从存档文件的 meta.lsf 中读取的信息
+type SaveMeta struct {
+	LeaderName string // 队伍领袖（主角）的名字
+	GameTime   int    // 游戏时长（秒），0 表示未知
+}
+
+// 游戏时长在不同游戏和版本中的字段名不同，按顺序查找
+var playtimeAttributes = []string{"TotalPlayTime", "TotalTimePlayed", "PlayTime", "TimePlayed", "GameTime"}
+
+// readSaveMeta 解析存档文件（.lsv）中的 meta.lsf
+func readSaveMeta(r io.ReaderAt, size int64) (*SaveMeta, error) {
+	data, err := readLSPKFile(r, size, "meta.lsf")
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := readLSFAttributes(data)
+	if err != nil {
+		return nil, fmt.Errorf("解析 meta.lsf 失败: %w", err)
+	}
+
+	meta := &SaveMeta{}
+	for _, attr := range attrs {
+		if attr.Name == "LeaderName" && meta.LeaderName == "" {
+			meta.LeaderName, _ = attr.Value.(string)
+		}
+	}
+	for _, name := range playtimeAttributes {
+		if meta.GameTime = lsfPlaytime(attrs, name); meta.GameTime > 0 {
+			break
+		}
+	}
+	return meta, nil
+}
+
+// readSaveMetaFile 解析本地存档文件
+func readSaveMetaFile(path string) (*SaveMeta, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return readSaveMeta(f, stat.Size())
+}
+
+// lsfPlaytime 游戏时长可能是以秒为单位的数字，也可能是包含 Hours/Minutes/Seconds 的节点
+func lsfPlaytime(attrs []lsfAttribute, name string) int {
+	var seconds float64
+	for _, attr := range attrs {
+		switch {
+		case attr.Name == name:
+			if v, ok := lsfNumber(attr.Value); ok && v > 0 {
+				return int(v)
+			}
+		case attr.Node == name:
+			v, _ := lsfNumber(attr.Value)
+			switch attr.Name {
+			case "Hours":
+				seconds += v * 3600
+			case "Minutes":
+				seconds += v * 60
+			case "Seconds":
+				seconds += v
+			}
+		}
+	}
+	return int(seconds)
+}
+
+func lsfNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// lsfAttribute LSF 中的一个属性，只解码数字和字符串，其他类型的值为 nil
+type lsfAttribute struct {
+	Node  string // 所属节点名
+	Name  string
+	Value any
+}
+
+// LSF 属性类型（LSLib 的 NodeAttribute.DataType）
+const (
+	lsfByte        = 1
+	lsfShort       = 2
+	lsfUShort      = 3
+	lsfInt         = 4
+	lsfUInt        = 5
+	lsfFloat       = 6
+	lsfDouble      = 7
+	lsfBool        = 19
+	lsfString      = 20
+	lsfPath        = 21
+	lsfFixedString = 22
+	lsfLSString    = 23
+	lsfULongLong   = 24
+	lsfLong        = 26
+	lsfInt8        = 27
+	lsfWString     = 29
+	lsfLSWString   = 30
+	lsfInt64       = 32
+)
+
+var errLSFCorrupt = errors.New("LSF 数据损坏")
+
+// readLSFAttributes 读取 LSF（二进制资源文件）中的所有属性。
+// 支持原罪2 终极版的 v3/v4 和博德之门3 的 v5 及以上版本
+func readLSFAttributes(data []byte) ([]lsfAttribute, error) {
+	if len(data) < 8 || string(data[:4]) != "LSOF" {
+		return nil, fmt.Errorf("%w: 文件头无效", errLSFCorrupt)
+	}
+	version := binary.LittleEndian.Uint32(data[4:])
+	pos := 12 // Magic, Version, EngineVersion
+	if version >= 5 {
+		pos = 16 // 博德之门3 的引擎版本为 64 位
+	}
+
+	// 各部分的解压后大小和压缩后大小，v6 起多了 keys
+	sections := 4
+	if version >= 6 {
+		sections = 5
+	}
+	if len(data) < pos+sections*8+8 {
+		return nil, errLSFCorrupt
+	}
+	sizes := make([][2]int, sections)
+	for i := range sizes {
+		sizes[i][0] = int(binary.LittleEndian.Uint32(data[pos:]))
+		sizes[i][1] = int(binary.LittleEndian.Uint32(data[pos+4:]))
+		pos += 8
+	}
+	if version >= 6 {
+		sizes = append(sizes[:1], sizes[2:]...) // keys 在最后，不需要读取
+	}
+	flags := uint32(data[pos])
+	extended := version >= 3 && binary.LittleEndian.Uint32(data[pos+4:]) == 1
+	pos += 8
+
+	var parts [4][]byte
+	for i, size := range sizes {
+		uncompressed, onDisk := size[0], size[1]
+		stored := onDisk
+		if onDisk == 0 {
+			stored = uncompressed // 未压缩
+		}
+		if pos+stored > len(data) {
+			return nil, errLSFCorrupt
+		}
+		raw := data[pos : pos+stored]
+		pos += stored
+
+		if onDisk == 0 || uncompressed == 0 {
+			parts[i] = raw
+			continue
+		}
+		// 字符串表使用 LZ4 块，节点、属性和值在 v2 起使用 LZ4 帧
+		part, err := decompressLS(raw, uncompressed, flags, i > 0 && version >= 2)
+		if err != nil {
+			return nil, err
+		}
+		parts[i] = part
+	}
+
+	names, err := readLSFNames(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	name := func(index uint32) string {
+		bucket, i := int(index>>16), int(index&0xFFFF)
+		if bucket < len(names) && i < len(names[bucket]) {
+			return names[bucket][i]
+		}
+		return ""
+	}
+
+	// 节点：名称索引，之后是父节点和第一个属性（扩展格式多一个兄弟节点）
+	nodeSize := 12
+	if extended {
+		nodeSize = 16
+	}
+	nodes := parts[1]
+	nodeNames := make([]string, len(nodes)/nodeSize)
+	firstAttr := make([]int32, len(nodeNames))
+	for i := range nodeNames {
+		n := nodes[i*nodeSize:]
+		nodeNames[i] = name(binary.LittleEndian.Uint32(n))
+		if extended {
+			firstAttr[i] = int32(binary.LittleEndian.Uint32(n[12:]))
+		} else {
+			firstAttr[i] = int32(binary.LittleEndian.Uint32(n[4:]))
+		}
+	}
+
+	attrData, values := parts[2], parts[3]
+	// 属性：名称索引、类型和长度，之后是所属节点（扩展格式为下一个属性和值的位置）
+	size := 12
+	if extended {
+		size = 16
+	}
+	count := len(attrData) / size
+	attrs := make([]lsfAttribute, count)
+	owner := make([]int, count)
+	for i := range owner {
+		owner[i] = -1
+	}
+
+	offset := 0
+	for i := 0; i < count; i++ {
+		a := attrData[i*size:]
+		typeAndLength := binary.LittleEndian.Uint32(a[4:])
+		typ, length := typeAndLength&0x3F, int(typeAndLength>>6)
+
+		if extended {
+			offset = int(binary.LittleEndian.Uint32(a[12:]))
+		} else if node := int(int32(binary.LittleEndian.Uint32(a[8:]))); node >= 0 {
+			owner[i] = node
+		}
+		if offset+length > len(values) {
+			return nil, errLSFCorrupt
+		}
+
+		attrs[i] = lsfAttribute{
+			Name:  name(binary.LittleEndian.Uint32(a)),
+			Value: decodeLSFValue(typ, values[offset:offset+length]),
+		}
+		offset += length
+	}
+
+	// 扩展格式中属性通过链表属于节点
+	if extended {
+		for node, first := range firstAttr {
+			for i, steps := int(first), 0; i >= 0 && i < count && steps < count; steps++ {
+				owner[i] = node
+				i = int(int32(binary.LittleEndian.Uint32(attrData[i*size+8:])))
+			}
+		}
+	}
+	for i := range attrs {
+		if owner[i] >= 0 && owner[i] < len(nodeNames) {
+			attrs[i].Node = nodeNames[owner[i]]
+		}
+	}
+	return attrs, nil
+}
+
+// readLSFNames 字符串表：按哈希分桶，每个桶中是长度加内容的字符串
+func readLSFNames(data []byte) ([][]string, error) {
+	r := bytes.NewReader(data)
+	var buckets uint32
+	if err := binary.Read(r, binary.LittleEndian, &buckets); err != nil {
+		return nil, errLSFCorrupt
+	}
+
+	var names [][]string
+	for b := uint32(0); b < buckets; b++ {
+		var count uint16
+		if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+			return nil, errLSFCorrupt
+		}
+		bucket := make([]string, count)
+		for i := range bucket {
+			var length uint16
+			if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+				return nil, errLSFCorrupt
+			}
+			s := make([]byte, length)
+			if _, err := io.ReadFull(r, s); err != nil {
+				return nil, errLSFCorrupt
+			}
+			bucket[i] = string(s)
+		}
+		names = append(names, bucket)
+	}
+	return names, nil
+}
+
+// decodeLSFValue 解码数字和字符串，整数为 int64 或 uint64，浮点数为 float64
+func decodeLSFValue(typ uint32, v []byte) any {
+	le := binary.LittleEndian
+	switch {
+	case (typ == lsfByte || typ == lsfBool) && len(v) >= 1:
+		return uint64(v[0])
+	case typ == lsfInt8 && len(v) >= 1:
+		return int64(int8(v[0]))
+	case typ == lsfShort && len(v) >= 2:
+		return int64(int16(le.Uint16(v)))
+	case typ == lsfUShort && len(v) >= 2:
+		return uint64(le.Uint16(v))
+	case typ == lsfInt && len(v) >= 4:
+		return int64(int32(le.Uint32(v)))
+	case typ == lsfUInt && len(v) >= 4:
+		return uint64(le.Uint32(v))
+	case typ == lsfFloat && len(v) >= 4:
+		return float64(math.Float32frombits(le.Uint32(v)))
+	case typ == lsfDouble && len(v) >= 8:
+		return math.Float64frombits(le.Uint64(v))
+	case (typ == lsfLong || typ == lsfInt64) && len(v) >= 8:
+		return int64(le.Uint64(v))
+	case typ == lsfULongLong && len(v) >= 8:
+		return le.Uint64(v)
+	case typ == lsfString || typ == lsfPath || typ == lsfFixedString || typ == lsfLSString ||
+		typ == lsfWString || typ == lsfLSWString:
+		return strings.TrimRight(string(v), "\x00")
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// lz4Literals 只包含字面量的 LZ4 块
+func lz4Literals(data []byte) []byte {
+	var block []byte
+	if n := len(data); n < 15 {
+		block = append(block, byte(n<<4))
+	} else {
+		block = append(block, 0xF0)
+		for n -= 15; n >= 255; n -= 255 {
+			block = append(block, 255)
+		}
+		block = append(block, byte(n))
+	}
+	return append(block, data...)
+}
+
+type testLSFAttr struct {
+	node  int
+	name  string
+	typ   uint32
+	value []byte
+}
+
+// buildLSF 生成未压缩的 LSF，所有名称放在同一个桶中
+func buildLSF(version uint32, extended bool, nodes []string, attrs []testLSFAttr) []byte {
+	le := binary.LittleEndian
+	var names []string
+	nameIndex := func(name string) uint32 {
+		for i, n := range names {
+			if n == name {
+				return uint32(i)
+			}
+		}
+		names = append(names, name)
+		return uint32(len(names) - 1)
+	}
+
+	var nodeData, attrData, values []byte
+	for i, name := range nodes {
+		first := int32(-1)
+		for j, attr := range attrs {
+			if attr.node == i {
+				first = int32(j)
+				break
+			}
+		}
+		if extended {
+			nodeData = le.AppendUint32(nodeData, nameIndex(name))
+			nodeData = le.AppendUint32(nodeData, 0xFFFFFFFF) // 父节点
+			nodeData = le.AppendUint32(nodeData, 0xFFFFFFFF) // 兄弟节点
+			nodeData = le.AppendUint32(nodeData, uint32(first))
+		} else {
+			nodeData = le.AppendUint32(nodeData, nameIndex(name))
+			nodeData = le.AppendUint32(nodeData, uint32(first))
+			nodeData = le.AppendUint32(nodeData, 0xFFFFFFFF)
+		}
+	}
+	for i, attr := range attrs {
+		attrData = le.AppendUint32(attrData, nameIndex(attr.name))
+		attrData = le.AppendUint32(attrData, attr.typ|uint32(len(attr.value))<<6)
+		if extended {
+			next := int32(-1)
+			for j := i + 1; j < len(attrs); j++ {
+				if attrs[j].node == attr.node {
+					next = int32(j)
+					break
+				}
+			}
+			attrData = le.AppendUint32(attrData, uint32(next))
+			attrData = le.AppendUint32(attrData, uint32(len(values)))
+		} else {
+			attrData = le.AppendUint32(attrData, uint32(attr.node))
+		}
+		values = append(values, attr.value...)
+	}
+
+	strings := le.AppendUint32(nil, 1)
+	strings = le.AppendUint16(strings, uint16(len(names)))
+	for _, name := range names {
+		strings = le.AppendUint16(strings, uint16(len(name)))
+		strings = append(strings, name...)
+	}
+
+	data := append([]byte("LSOF"), le.AppendUint32(nil, version)...)
+	if version >= 5 {
+		data = le.AppendUint64(data, 0)
+	} else {
+		data = le.AppendUint32(data, 0)
+	}
+	sections := [][]byte{strings, nodeData, attrData, values}
+	for i, section := range sections {
+		data = le.AppendUint32(data, uint32(len(section)))
+		data = le.AppendUint32(data, 0) // 未压缩
+		if i == 0 && version >= 6 {
+			data = le.AppendUint64(data, 0) // keys
+		}
+	}
+	format := uint32(0)
+	if extended {
+		format = 1
+	}
+	data = append(data, 0, 0, 0, 0)
+	data = le.AppendUint32(data, format)
+	for _, section := range sections {
+		data = append(data, section...)
+	}
+	return data
+}
+
+func lspkName(name string) []byte {
+	b := make([]byte, 256)
+	copy(b, name)
+	return b
+}
+
+// buildLSPK18 博德之门3 格式的存档包
+func buildLSPK18(name string, content []byte) []byte {
+	le := binary.LittleEndian
+	const headerSize = 40
+	entry := lspkName(name)
+	entry = le.AppendUint32(entry, headerSize)
+	entry = le.AppendUint16(entry, 0)
+	entry = append(entry, 0, 0) // ArchivePart, Flags（未压缩）
+	entry = le.AppendUint32(entry, uint32(len(content)))
+	entry = le.AppendUint32(entry, 0)
+	list := lz4Literals(entry)
+
+	data := append([]byte("LSPK"), le.AppendUint32(nil, 18)...)
+	data = le.AppendUint64(data, uint64(headerSize+len(content)))
+	data = le.AppendUint32(data, uint32(8+len(list)))
+	data = append(data, make([]byte, headerSize-len(data))...)
+	data = append(data, content...)
+	data = le.AppendUint32(data, 1)
+	data = le.AppendUint32(data, uint32(len(list)))
+	return append(data, list...)
+}
+
+// buildLSPK13 原罪2 终极版格式的存档包，文件头在末尾
+func buildLSPK13(name string, content []byte) []byte {
+	le := binary.LittleEndian
+	entry := lspkName(name)
+	entry = le.AppendUint32(entry, 0)
+	entry = le.AppendUint32(entry, uint32(len(content)))
+	entry = le.AppendUint32(entry, 0)
+	entry = append(entry, make([]byte, 12)...) // ArchivePart, Flags, Crc
+	list := append(le.AppendUint32(nil, 1), lz4Literals(entry)...)
+
+	// 文件内容、文件列表，最后是文件头、文件头大小和签名
+	data := append(append([]byte{}, content...), list...)
+	data = le.AppendUint32(data, 13)
+	data = le.AppendUint32(data, uint32(len(content)))
+	data = le.AppendUint32(data, uint32(len(list)))
+	data = append(data, make([]byte, 20)...) // NumParts, Flags, Priority, Md5
+	data = le.AppendUint32(data, 32+8)
+	return append(data, "LSPK"...)
+}
+
+func TestReadSaveMeta(t *testing.T) {
+	le := binary.LittleEndian
+	bg3 := buildLSF(7, true, []string{"MetaData"}, []testLSFAttr{
+		{0, "LeaderName", lsfLSString, []byte("Tav\x00")},
+		{0, "TotalPlayTime", lsfUInt, le.AppendUint32(nil, 3725)},
+	})
+	dos2 := buildLSF(3, false, []string{"MetaData", "GameTime"}, []testLSFAttr{
+		{0, "LeaderName", lsfLSWString, []byte("Ifan\x00")},
+		{1, "Hours", lsfUInt, le.AppendUint32(nil, 2)},
+		{1, "Minutes", lsfUInt, le.AppendUint32(nil, 30)},
+		{1, "Seconds", lsfUInt, le.AppendUint32(nil, 5)},
+	})
+
+	tests := []struct {
+		name string
+		data []byte
+		want SaveMeta
+	}{
+		{"博德之门3", buildLSPK18("meta.lsf", bg3), SaveMeta{LeaderName: "Tav", GameTime: 3725}},
+		{"原罪2 终极版", buildLSPK13("Meta.lsf", dos2), SaveMeta{LeaderName: "Ifan", GameTime: 2*3600 + 30*60 + 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, err := readSaveMeta(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *meta != tt.want {
+				t.Errorf("readSaveMeta() = %+v, want %+v", *meta, tt.want)
+			}
+		})
+	}
+}
+
+func TestLZ4DecompressBlock(t *testing.T) {
+	// "abc" 之后回溯 3 字节复制 9 字节（与正在写入的数据重叠），最后是字面量 "x"
+	block := []byte{0x35, 'a', 'b', 'c', 3, 0, 0x10, 'x'}
+	got, err := lz4DecompressBlock(block, 13)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "abcabcabcabcx" {
+		t.Errorf("lz4DecompressBlock() = %q", got)
+	}
+
+	long := bytes.Repeat([]byte("0123456789"), 40)
+	if got, err := lz4DecompressBlock(lz4Literals(long), len(long)); err != nil || !bytes.Equal(got, long) {
+		t.Errorf("长字面量解压失败: %v", err)
+	}
+
+	if _, err := lz4DecompressBlock([]byte{0x35, 'a', 'b', 'c', 9, 0}, 13); err == nil {
+		t.Error("越界的匹配应该报错")
+	}
+}
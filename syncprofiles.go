@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// SyncProfile 命名的同步配置，各自有服务器、存档目录、过滤规则和自动同步开关。
+// 当前使用的配置保存在 Config 的顶层字段中，切换时互相复制
+type SyncProfile struct {
+	Name           string   `json:"name"`
+	Game           string   `json:"game"`
+	NebulaURL      string   `json:"nebula_url"`
+	SavePath       string   `json:"save_path"`
+	PlayerProfiles []string `json:"player_profiles,omitempty"`
+	GameModes      []string `json:"game_modes"`
+	AutoSync       bool     `json:"auto_sync"`
+	AutoUpload     bool     `json:"auto_upload"`
+	AutoRestore    bool     `json:"auto_restore"`
+	Mirrors        []string `json:"mirrors,omitempty"` // 上传时同时上传到这些配置的服务器
+}
+
+// currentSyncProfile 由当前设置生成同步配置
+func (cfg *Config) currentSyncProfile() SyncProfile {
+	return SyncProfile{
+		Name:           cfg.ActiveProfile,
+		Game:           cfg.Game,
+		NebulaURL:      cfg.NebulaURL,
+		SavePath:       cfg.SavePath,
+		PlayerProfiles: cfg.PlayerProfiles,
+		GameModes:      cfg.GameModes,
+		AutoSync:       cfg.AutoSync,
+		AutoUpload:     cfg.AutoUpload,
+		AutoRestore:    cfg.AutoRestore,
+		Mirrors:        cfg.Mirrors,
+	}
+}
+
+// applySyncProfile 切换到同步配置。手动编写的配置可能没有游戏和游戏模式，
+// 没有游戏时沿用当前游戏，没有游戏模式（不同于空列表）时使用该游戏的默认模式
+func (cfg *Config) applySyncProfile(p SyncProfile) {
+	if p.Game == "" {
+		p.Game = gameByID(cfg.Game).ID
+	}
+	if p.GameModes == nil {
+		p.GameModes = slices.Clone(gameByID(p.Game).DefaultModes)
+	}
+
+	cfg.ActiveProfile = p.Name
+	cfg.Game = p.Game
+	cfg.NebulaURL = p.NebulaURL
+	cfg.SavePath = p.SavePath
+	cfg.PlayerProfiles = p.PlayerProfiles
+	cfg.GameModes = p.GameModes
+	cfg.AutoSync = p.AutoSync
+	cfg.AutoUpload = p.AutoUpload
+	cfg.AutoRestore = p.AutoRestore
+	cfg.Mirrors = p.Mirrors
+}
+
+// storeActiveProfile 将当前设置保存回同名的同步配置
+func (cfg *Config) storeActiveProfile() {
+	if cfg.ActiveProfile == "" {
+		return
+	}
+	current := cfg.currentSyncProfile()
+	for i := range cfg.SyncProfiles {
+		if cfg.SyncProfiles[i].Name == current.Name {
+			cfg.SyncProfiles[i] = current
+			return
+		}
+	}
+	cfg.SyncProfiles = append(cfg.SyncProfiles, current)
+}
+
+// 第一次切换配置前，未命名的当前设置以此名称保存，避免被切换覆盖
+const defaultSyncProfileName = "默认"
+
+// nameActiveProfile 当前设置还没有名称时，以不重复的默认名称保存为同步配置
+func (cfg *Config) nameActiveProfile() {
+	if cfg.ActiveProfile != "" {
+		return
+	}
+	name := defaultSyncProfileName
+	for i := 2; ; i++ {
+		if _, exists := cfg.findSyncProfile(name); !exists {
+			break
+		}
+		name = fmt.Sprintf("%s %d", defaultSyncProfileName, i)
+	}
+	cfg.ActiveProfile = name
+	cfg.storeActiveProfile()
+}
+
+// findSyncProfile 按名称查找同步配置
+func (cfg *Config) findSyncProfile(name string) (SyncProfile, bool) {
+	for _, p := range cfg.SyncProfiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return SyncProfile{}, false
+}
+
+// isSyncedFolder 存档文件夹是否需要同步
+func (c *Client) isSyncedFolder(folderName string) bool {
+	return c.game().MatchesMode(folderName, c.config.Load().GameModes)
+}
+
+// mirrorAPI 镜像服务器的 API 客户端，按地址缓存
+func (c *Client) mirrorAPI(url string) (*NebulaAPI, error) {
+	c.mirrorLock.Lock()
+	defer c.mirrorLock.Unlock()
+
+	if api, ok := c.mirrorAPIs[url]; ok {
+		return api, nil
+	}
+	cfg := c.config.Load()
+	api, err := NewNebulaAPI(url, cfg.DeviceID, cfg.Network)
+	if err != nil {
+		return nil, err
+	}
+	if c.mirrorAPIs == nil {
+		c.mirrorAPIs = make(map[string]*NebulaAPI)
+	}
+	c.mirrorAPIs[url] = api
+	return api, nil
+}
+
+// mirrorTargets 需要镜像上传的同步配置，跳过没有服务器或与当前服务器相同的配置
+func (cfg *Config) mirrorTargets() []SyncProfile {
+	var targets []SyncProfile
+	for _, name := range cfg.Mirrors {
+		p, ok := cfg.findSyncProfile(name)
+		if !ok || p.NebulaURL == "" || p.NebulaURL == cfg.NebulaURL {
+			continue
+		}
+		targets = append(targets, p)
+	}
+	return targets
+}
+
+// mirrorUpload 将已上传的存档同时上传到镜像配置的服务器。
+// 在后台运行，镜像配置和限速由调用方在启动前取好，不读取可能被替换的 c.config
+func (c *Client) mirrorUpload(targets []SyncProfile, upload, download int64, fileName string, data []byte, info *SaveInfo) {
+	for _, p := range targets {
+		api, err := c.mirrorAPI(p.NebulaURL)
+		if err != nil {
+			log.Printf("镜像上传到 %s 失败: %v\n", p.Name, err)
+			continue
+		}
+		api.SetBandwidth(upload, download)
+
+		if _, err := api.UploadSave(context.Background(), fileName, data, info, nil); err != nil {
+			log.Printf("镜像上传到 %s 失败: %v\n", p.Name, err)
+			continue
+		}
+		log.Printf("已镜像上传到 %s: %s\n", p.Name, fileName)
+	}
+}
+
+// activeProfileLabel 当前使用的同步配置名称
+func (c *Client) activeProfileLabel() string {
+	name := c.config.Load().ActiveProfile
+	if name == "" {
+		name = "（未命名）"
+	}
+	return "当前同步配置: " + name
+}
+
+// showSyncProfiles 管理命名的同步配置
+func (c *Client) showSyncProfiles() {
+	win := c.app.NewWindow("同步配置")
+	win.Resize(fyne.NewSize(500, 450))
+
+	active := widget.NewLabel("")
+	updateActive := func() {
+		active.SetText(fmt.Sprintf("%s  服务器: %s", c.activeProfileLabel(), c.config.Load().NebulaURL))
+	}
+	updateActive()
+
+	selected := -1
+	list := widget.NewList(
+		func() int { return len(c.config.Load().SyncProfiles) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			cfg := c.config.Load()
+			if id >= len(cfg.SyncProfiles) {
+				return
+			}
+			p := cfg.SyncProfiles[id]
+			text := fmt.Sprintf("%s - %s", p.Name, p.NebulaURL)
+			if p.Name == cfg.ActiveProfile {
+				text = "● " + text
+			}
+			item.(*widget.Label).SetText(text)
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) { selected = id }
+
+	// 镜像：上传时同时上传到其他配置的服务器
+	mirrors := widget.NewCheckGroup(nil, nil)
+	updateMirrors := func() {
+		cfg := c.config.Load()
+		var options []string
+		for _, p := range cfg.SyncProfiles {
+			if p.Name != cfg.ActiveProfile {
+				options = append(options, p.Name)
+			}
+		}
+		mirrors.Options = options
+		mirrors.Selected = nil
+		for _, name := range cfg.Mirrors {
+			for _, option := range options {
+				if option == name {
+					mirrors.Selected = append(mirrors.Selected, name)
+				}
+			}
+		}
+		mirrors.Refresh()
+	}
+	updateMirrors()
+
+	// 同步配置保存在配置文件中，直接修改配置文件的内容，环境变量和命令行参数的覆盖值不会被存入同步配置
+	save := func(edit func(file *Config)) bool {
+		if err := c.updateFileConfig(edit); err != nil {
+			dialog.ShowError(err, win)
+			return false
+		}
+		return true
+	}
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("配置名称，如 家里的服务器")
+	saveAsBtn := widget.NewButton("将当前设置保存为新配置", func() {
+		name := strings.TrimSpace(nameEntry.Text)
+		if name == "" {
+			dialog.ShowError(fmt.Errorf("请输入配置名称"), win)
+			return
+		}
+		if _, exists := c.config.Load().findSyncProfile(name); exists {
+			dialog.ShowError(fmt.Errorf("配置 %q 已存在", name), win)
+			return
+		}
+
+		saved := save(func(file *Config) {
+			file.storeActiveProfile()
+			file.ActiveProfile = name
+			file.storeActiveProfile()
+		})
+		if saved {
+			nameEntry.SetText("")
+			list.Refresh()
+			updateActive()
+			updateMirrors()
+		}
+	})
+
+	switchBtn := widget.NewButton("切换到所选配置", func() {
+		cfg := c.config.Load()
+		if selected < 0 || selected >= len(cfg.SyncProfiles) {
+			return
+		}
+		p := cfg.SyncProfiles[selected]
+		if p.Name == cfg.ActiveProfile {
+			return
+		}
+
+		saved := save(func(file *Config) {
+			// 未命名的当前设置先保存为默认配置，之后可以切换回来
+			file.nameActiveProfile()
+			file.storeActiveProfile()
+			file.applySyncProfile(p)
+		})
+		if saved {
+			list.Refresh()
+			updateActive()
+			updateMirrors()
+			dialog.ShowInformation("成功", fmt.Sprintf("已切换到 %s", p.Name), win)
+		}
+	})
+
+	deleteBtn := widget.NewButton("删除所选配置", func() {
+		cfg := c.config.Load()
+		if selected < 0 || selected >= len(cfg.SyncProfiles) {
+			return
+		}
+		p := cfg.SyncProfiles[selected]
+		if p.Name == cfg.ActiveProfile {
+			dialog.ShowError(fmt.Errorf("不能删除当前使用的配置"), win)
+			return
+		}
+
+		dialog.ShowConfirm("删除配置", fmt.Sprintf("确定删除配置 %s 吗？", p.Name), func(ok bool) {
+			if !ok {
+				return
+			}
+			selected = -1
+			list.UnselectAll()
+			saved := save(func(file *Config) {
+				file.SyncProfiles = slices.DeleteFunc(file.SyncProfiles, func(sp SyncProfile) bool {
+					return sp.Name == p.Name
+				})
+			})
+			if saved {
+				list.Refresh()
+				updateMirrors()
+			}
+		}, win)
+	})
+
+	mirrorBtn := widget.NewButton("保存镜像设置", func() {
+		saved := save(func(file *Config) {
+			file.Mirrors = mirrors.Selected
+			file.storeActiveProfile()
+		})
+		if saved {
+			dialog.ShowInformation("成功", "镜像设置已保存", win)
+		}
+	})
+
+	win.SetContent(container.NewPadded(container.NewBorder(
+		container.NewVBox(
+			active,
+			widget.NewLabel("修改当前配置的服务器、存档目录等请使用\"设置\"。"),
+			container.NewBorder(nil, nil, nil, saveAsBtn, nameEntry),
+		),
+		container.NewVBox(
+			container.NewHBox(switchBtn, deleteBtn),
+			widget.NewLabel("上传时同时上传到以下配置的服务器（镜像）:"),
+			mirrors,
+			mirrorBtn,
+		),
+		nil, nil,
+		list,
+	)))
+	win.Show()
+}
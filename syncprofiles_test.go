@@ -0,0 +1,37 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestApplySyncProfileDefaults(t *testing.T) {
+	tests := []struct {
+		name      string
+		current   string
+		profile   SyncProfile
+		wantGame  string
+		wantModes []string
+	}{
+		{"沿用当前游戏", dos2Game.ID, SyncProfile{Name: "NAS"}, dos2Game.ID, dos2Game.DefaultModes},
+		{"没有当前游戏", "", SyncProfile{Name: "NAS"}, bg3Game.ID, bg3Game.DefaultModes},
+		{"没有模式使用默认", dos2Game.ID, SyncProfile{Name: "NAS", Game: bg3Game.ID}, bg3Game.ID, bg3Game.DefaultModes},
+		{"空列表同步所有模式", bg3Game.ID, SyncProfile{Name: "NAS", GameModes: []string{}}, bg3Game.ID, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Game: tt.current}
+			cfg.applySyncProfile(tt.profile)
+			if cfg.Game != tt.wantGame || !slices.Equal(cfg.GameModes, tt.wantModes) || (cfg.GameModes == nil) != (tt.wantModes == nil) {
+				t.Errorf("game=%q modes=%#v, want %q %#v", cfg.Game, cfg.GameModes, tt.wantGame, tt.wantModes)
+			}
+		})
+	}
+
+	// 默认模式是副本，修改配置不会改写游戏定义
+	cfg := &Config{}
+	cfg.applySyncProfile(SyncProfile{Name: "NAS"})
+	if len(cfg.GameModes) > 0 && &cfg.GameModes[0] == &bg3Game.DefaultModes[0] {
+		t.Error("GameModes 与游戏定义共用同一个切片")
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// BandwidthLimits 上传/下载限速，单位 KB/s，0 表示不限速
+type BandwidthLimits struct {
+	UploadKBps       int `json:"upload_kbps"`
+	DownloadKBps     int `json:"download_kbps"`
+	GameUploadKBps   int `json:"game_upload_kbps"`   // 游戏运行时的上传限速
+	GameDownloadKBps int `json:"game_download_kbps"` // 游戏运行时的下载限速
+}
+
+// 默认不限速，需要时在设置中开启（如游戏运行时限制上传，避免影响联机）
+func defaultBandwidthLimits() BandwidthLimits {
+	return BandwidthLimits{}
+}
+
+// Rates 返回当前生效的上传和下载速率（字节/秒），0 表示不限速
+func (b BandwidthLimits) Rates(gameRunning bool) (upload, download int64) {
+	upload, download = int64(b.UploadKBps)*1024, int64(b.DownloadKBps)*1024
+	if gameRunning {
+		upload = lowerLimit(upload, int64(b.GameUploadKBps)*1024)
+		download = lowerLimit(download, int64(b.GameDownloadKBps)*1024)
+	}
+	return upload, download
+}
+
+// Describe 限速说明
+func (b BandwidthLimits) Describe(gameRunning bool) string {
+	upload, download := b.Rates(gameRunning)
+	return fmt.Sprintf("上传 %s，下载 %s", formatRate(upload), formatRate(download))
+}
+
+// lowerLimit 取两个限速中更严格的一个，0 表示不限速
+func lowerLimit(a, b int64) int64 {
+	if a == 0 {
+		return b
+	}
+	if b == 0 {
+		return a
+	}
+	return min(a, b)
+}
+
+func formatRate(bytesPerSec int64) string {
+	if bytesPerSec <= 0 {
+		return "不限速"
+	}
+	return formatSize(bytesPerSec) + "/s"
+}
+
+// rateLimiter 令牌桶限速器，最多允许 1 秒的突发流量
+type rateLimiter struct {
+	lock   sync.Mutex
+	rate   int64 // 字节/秒，0 表示不限速
+	tokens float64
+	last   time.Time
+}
+
+// SetRate 修改速率，对进行中的传输立即生效
+func (l *rateLimiter) SetRate(bytesPerSec int64) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.rate != bytesPerSec {
+		l.rate = bytesPerSec
+		l.tokens = 0
+		l.last = time.Now()
+	}
+}
+
+// chunk 单次读取的最大字节数，保证限速平滑
+func (l *rateLimiter) chunk() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.rate <= 0 {
+		return 0
+	}
+	return int(max(l.rate/10, 1024))
+}
+
+// wait 消耗 n 个字节的令牌，令牌不足时等待
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	l.lock.Lock()
+	if l.rate <= 0 {
+		l.lock.Unlock()
+		return nil
+	}
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * float64(l.rate)
+	l.tokens = min(l.tokens, float64(l.rate))
+	l.last = now
+	l.tokens -= float64(n)
+
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / float64(l.rate) * float64(time.Second))
+	}
+	l.lock.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// throttledReader 按限速器的速率读取
+type throttledReader struct {
+	ctx     context.Context
+	r       io.ReadCloser
+	limiter *rateLimiter
+}
+
+func (tr *throttledReader) Read(p []byte) (int, error) {
+	if chunk := tr.limiter.chunk(); chunk > 0 && len(p) > chunk {
+		p = p[:chunk]
+	}
+
+	n, err := tr.r.Read(p)
+	if n > 0 {
+		if waitErr := tr.limiter.wait(tr.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+func (tr *throttledReader) Close() error {
+	return tr.r.Close()
+}
+
+// throttledTransport 对请求体和响应体限速，所有经过该 Transport 的请求共享同一组限速器
+type throttledTransport struct {
+	base     http.RoundTripper
+	upload   *rateLimiter
+	download *rateLimiter
+}
+
+func newThrottledTransport(base http.RoundTripper) *throttledTransport {
+	return &throttledTransport{
+		base:     base,
+		upload:   &rateLimiter{},
+		download: &rateLimiter{},
+	}
+}
+
+func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody {
+		req = req.Clone(req.Context())
+		req.Body = &throttledReader{ctx: req.Context(), r: req.Body, limiter: t.upload}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = &throttledReader{ctx: req.Context(), r: resp.Body, limiter: t.download}
+	return resp, nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 进度界面的最短刷新间隔
+const transferRefreshInterval = 250 * time.Millisecond
+
+// ProgressFunc 传输进度回调，total 未知时为 -1
+type ProgressFunc func(done, total int64)
+
+// progressReader 读取时报告进度
+type progressReader struct {
+	r        io.Reader
+	done     int64
+	total    int64
+	progress ProgressFunc
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	if n > 0 {
+		pr.done += int64(n)
+		pr.progress(pr.done, pr.total)
+	}
+	return n, err
+}
+
+// transfer 正在进行的上传或下载
+type transfer struct {
+	op      string // "上传" 或 "下载"
+	name    string
+	started time.Time
+	cancel  context.CancelFunc
+
+	lock        sync.Mutex
+	done, total int64
+	lastRefresh time.Time
+}
+
+// Describe 进度描述，包含速度和剩余时间
+func (t *transfer) Describe() string {
+	t.lock.Lock()
+	done, total := t.done, t.total
+	t.lock.Unlock()
+
+	text := fmt.Sprintf("%s %s: %s", t.op, t.name, formatSize(done))
+	if total > 0 {
+		text += fmt.Sprintf(" / %s (%d%%)", formatSize(total), done*100/total)
+	}
+
+	elapsed := time.Since(t.started).Seconds()
+	if elapsed < 1 || done == 0 {
+		return text
+	}
+	speed := float64(done) / elapsed
+	text += fmt.Sprintf("，%s/s", formatSize(int64(speed)))
+	if total > done {
+		eta := time.Duration(float64(total-done)/speed) * time.Second
+		text += fmt.Sprintf("，剩余 %s", eta.Round(time.Second))
+	}
+	return text
+}
+
+// Fraction 完成比例，总大小未知时为 0
+func (t *transfer) Fraction() float64 {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.total <= 0 {
+		return 0
+	}
+	return float64(t.done) / float64(t.total)
+}
+
+// beginTransfer 开始一次可取消的传输，返回的 ctx 在点击取消或调用 end 时取消。
+// 同时只显示一个传输，新的传输会替换界面上显示的旧传输
+func (c *Client) beginTransfer(parent context.Context, op, name string) (ctx context.Context, progress ProgressFunc, end func()) {
+	ctx, cancel := context.WithCancel(parent)
+	t := &transfer{op: op, name: name, started: time.Now(), cancel: cancel, total: -1}
+
+	c.transferLock.Lock()
+	c.transfer = t
+	c.transferLock.Unlock()
+	c.refreshTransfer()
+
+	progress = func(done, total int64) {
+		t.lock.Lock()
+		t.done, t.total = done, total
+		refresh := time.Since(t.lastRefresh) >= transferRefreshInterval || done == total
+		if refresh {
+			t.lastRefresh = time.Now()
+		}
+		t.lock.Unlock()
+
+		if refresh {
+			c.refreshTransfer()
+		}
+	}
+
+	end = func() {
+		cancel()
+
+		c.transferLock.Lock()
+		if c.transfer == t {
+			c.transfer = nil
+		}
+		c.transferLock.Unlock()
+		c.refreshTransfer()
+	}
+
+	return ctx, progress, end
+}
+
+// cancelTransfer 取消界面上显示的传输
+func (c *Client) cancelTransfer() {
+	c.transferLock.Lock()
+	t := c.transfer
+	c.transferLock.Unlock()
+
+	if t != nil {
+		t.cancel()
+	}
+}
+
+// makeTransferBar 主界面上的传输进度条，没有传输时隐藏
+func (c *Client) makeTransferBar() fyne.CanvasObject {
+	c.transferProgress = widget.NewProgressBar()
+	c.transferLabel = widget.NewLabel("")
+
+	cancelBtn := widget.NewButton("取消", func() {
+		c.cancelTransfer()
+	})
+
+	c.transferBox = container.NewBorder(nil, nil, nil, cancelBtn,
+		container.NewVBox(c.transferLabel, c.transferProgress),
+	)
+	c.transferBox.Hide()
+	return c.transferBox
+}
+
+// refreshTransfer 更新主界面进度条和托盘菜单中的传输状态
+func (c *Client) refreshTransfer() {
+	c.transferLock.Lock()
+	t := c.transfer
+	c.transferLock.Unlock()
+
+	text := "没有进行中的传输"
+	var fraction float64
+	if t != nil {
+		text = t.Describe()
+		fraction = t.Fraction()
+	}
+
+	fyne.Do(func() {
+		if c.transferBox != nil {
+			if t == nil {
+				c.transferBox.Hide()
+			} else {
+				c.transferLabel.SetText(text)
+				c.transferProgress.SetValue(fraction)
+				c.transferBox.Show()
+			}
+		}
+
+		if c.trayStatus != nil {
+			c.trayStatus.Label = text
+			c.trayMenu.Refresh()
+		}
+	})
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// NetworkConfig 连接服务器的网络设置
+type NetworkConfig struct {
+	ProxyURL   string   `json:"proxy_url"`   // http://、https:// 或 socks5:// 代理，为空时使用系统代理环境变量
+	CAFile     string   `json:"ca_file"`     // 额外信任的 CA 证书 (PEM)
+	ClientCert string   `json:"client_cert"` // 双向 TLS 客户端证书 (PEM)
+	ClientKey  string   `json:"client_key"`  // 双向 TLS 客户端私钥 (PEM)
+	Timeouts   Timeouts `json:"timeouts"`
+
+	// Compression 服务端声明支持（Accept-Encoding: gzip）后用 gzip 压缩请求体，返回 415 时自动关闭
+	Compression bool `json:"compression"`
+}
+
+// Timeouts 各类操作的超时，0 表示使用默认值
+type Timeouts struct {
+	ConnectSeconds  int `json:"connect_seconds"`  // 建立连接和 TLS 握手
+	RequestSeconds  int `json:"request_seconds"`  // 普通 API 请求
+	TransferMinutes int `json:"transfer_minutes"` // 上传和下载存档
+}
+
+func defaultTimeouts() Timeouts {
+	return Timeouts{
+		ConnectSeconds:  10,
+		RequestSeconds:  30,
+		TransferMinutes: 30,
+	}
+}
+
+func (t Timeouts) Connect() time.Duration {
+	if t.ConnectSeconds <= 0 {
+		return time.Duration(defaultTimeouts().ConnectSeconds) * time.Second
+	}
+	return time.Duration(t.ConnectSeconds) * time.Second
+}
+
+func (t Timeouts) Request() time.Duration {
+	if t.RequestSeconds <= 0 {
+		return time.Duration(defaultTimeouts().RequestSeconds) * time.Second
+	}
+	return time.Duration(t.RequestSeconds) * time.Second
+}
+
+func (t Timeouts) Transfer() time.Duration {
+	if t.TransferMinutes <= 0 {
+		return time.Duration(defaultTimeouts().TransferMinutes) * time.Minute
+	}
+	return time.Duration(t.TransferMinutes) * time.Minute
+}
+
+// parseProxyURL 解析代理地址，支持 http、https 和 socks5
+func parseProxyURL(proxy string) (*url.URL, error) {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("代理地址无效: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("不支持的代理协议: %q (支持 http、https、socks5)", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("代理地址缺少主机: %s", proxy)
+	}
+	return u, nil
+}
+
+// tlsConfig 加载额外的 CA 和客户端证书
+func (cfg NetworkConfig) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取 CA 证书失败: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA 证书中没有有效的 PEM 证书: %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.ClientCert != "" || cfg.ClientKey != "" {
+		if cfg.ClientCert == "" || cfg.ClientKey == "" {
+			return nil, fmt.Errorf("客户端证书和私钥必须同时设置")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCert, cfg.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("加载客户端证书失败: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// buildTransport 按网络设置创建 http.Transport
+func buildTransport(cfg NetworkConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	dialer := &net.Dialer{
+		Timeout:   cfg.Timeouts.Connect(),
+		KeepAlive: 30 * time.Second,
+	}
+	transport.DialContext = dialer.DialContext
+	transport.TLSHandshakeTimeout = cfg.Timeouts.Connect()
+
+	// 复用连接：浏览存档时会连续发出大量请求
+	transport.ForceAttemptHTTP2 = true
+	transport.MaxIdleConns = 32
+	transport.MaxIdleConnsPerHost = 8
+	transport.IdleConnTimeout = 90 * time.Second
+
+	// 由 NebulaAPI 自行请求和解压 gzip 响应，限速按实际传输的字节计算
+	transport.DisableCompression = true
+	// 限速后大文件的传输时间可能很长，传输本身的时长由每个操作的超时控制，这里只限制等待响应头的时间
+	transport.ResponseHeaderTimeout = cfg.Timeouts.Request()
+
+	if cfg.ProxyURL != "" {
+		proxy, err := parseProxyURL(cfg.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	tlsConfig, err := cfg.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	return transport, nil
+}
+
+// ConnectionStep 连接测试的一个步骤
+type ConnectionStep struct {
+	Name string
+	Err  error
+}
+
+// testConnection 逐步测试与服务器的连接，遇到第一个失败的步骤即停止
+func testConnection(ctx context.Context, baseURL, deviceID string, cfg NetworkConfig) []ConnectionStep {
+	var steps []ConnectionStep
+	step := func(name string, fn func() error) bool {
+		err := fn()
+		steps = append(steps, ConnectionStep{Name: name, Err: err})
+		return err == nil
+	}
+
+	var server *url.URL
+	ok := step("解析服务器地址", func() error {
+		u, err := url.Parse(baseURL)
+		if err != nil {
+			return err
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("地址必须以 http:// 或 https:// 开头")
+		}
+		if u.Host == "" {
+			return fmt.Errorf("地址缺少主机名")
+		}
+		server = u
+		return nil
+	})
+	if !ok {
+		return steps
+	}
+
+	var api *NebulaAPI
+	ok = step("加载代理和证书设置", func() error {
+		var err error
+		api, err = NewNebulaAPI(baseURL, deviceID, cfg)
+		return err
+	})
+	if !ok {
+		return steps
+	}
+
+	dialer := &net.Dialer{Timeout: cfg.Timeouts.Connect()}
+	if cfg.ProxyURL != "" {
+		// 经过代理时只能确认代理可达，目标服务器由代理连接
+		proxy, _ := parseProxyURL(cfg.ProxyURL)
+		ok = step("连接代理 "+proxy.Host, func() error {
+			conn, err := dialer.DialContext(ctx, "tcp", hostPort(proxy))
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		})
+	} else {
+		ok = step("解析域名 "+server.Hostname(), func() error {
+			_, err := net.DefaultResolver.LookupHost(ctx, server.Hostname())
+			return err
+		}) && step("连接 "+hostPort(server), func() error {
+			conn, err := dialer.DialContext(ctx, "tcp", hostPort(server))
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		})
+
+		if ok && server.Scheme == "https" {
+			ok = step("TLS 握手", func() error {
+				tlsConfig, err := cfg.tlsConfig()
+				if err != nil {
+					return err
+				}
+				tlsConfig.ServerName = server.Hostname()
+
+				tlsDialer := &tls.Dialer{NetDialer: dialer, Config: tlsConfig}
+				conn, err := tlsDialer.DialContext(ctx, "tcp", hostPort(server))
+				if err != nil {
+					return err
+				}
+				return conn.Close()
+			})
+		}
+	}
+	if !ok {
+		return steps
+	}
+
+	step("服务器健康检查", func() error {
+		return api.CheckHealth(ctx)
+	})
+	return steps
+}
+
+// hostPort 返回 host:port，没有端口时使用协议默认端口
+func hostPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return net.JoinHostPort(u.Hostname(), port)
+	}
+	switch u.Scheme {
+	case "https":
+		return net.JoinHostPort(u.Hostname(), "443")
+	case "socks5", "socks5h":
+		return net.JoinHostPort(u.Hostname(), "1080")
+	}
+	return net.JoinHostPort(u.Hostname(), "80")
+}
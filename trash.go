@@ -0,0 +1,449 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 回收站自动清除的检查间隔
+const trashPurgeInterval = 6 * time.Hour
+
+// TrashItem 回收站中的存档
+type TrashItem struct {
+	Save  *SaveGame
+	Local bool         // 服务端不支持回收站时在本地保留的副本
+	Path  string       // 本地副本的 zip 路径
+	Mods  *ModSnapshot // 本地副本上传时附带的模组设置，恢复时重新上传
+}
+
+// localTrashMeta 本地回收站中与副本一起保存的存档信息
+type localTrashMeta struct {
+	SaveGame
+	Mods *ModSnapshot `json:"mods,omitempty"`
+}
+
+// 本地回收站目录
+func getTrashDir() string {
+	return filepath.Join(getAppDataDir(), "trash")
+}
+
+// moveToTrash 将云端存档移入回收站，服务端不支持时先下载副本到本地再删除
+func (c *Client) moveToTrash(ctx context.Context, save *SaveGame) error {
+	err := c.api.Load().TrashSave(ctx, save.ID)
+	if err == nil {
+		recordTrashTime(save.ID, time.Now())
+		return nil
+	}
+	if !errors.Is(err, ErrTrashUnsupported) {
+		return err
+	}
+
+	log.Printf("服务端不支持回收站，在本地保留副本: %s\n", save.FileName)
+
+	data, err := c.api.Load().DownloadSave(ctx, save.ID, nil)
+	if err != nil {
+		return fmt.Errorf("下载存档副本失败: %w", err)
+	}
+
+	if err := os.MkdirAll(getTrashDir(), 0755); err != nil {
+		return fmt.Errorf("创建回收站目录失败: %w", err)
+	}
+
+	// 模组设置保存在服务端，删除后无法再获取，一起保存到本地
+	mods, err := c.api.Load().GetModSnapshot(ctx, save.ID)
+	if err != nil {
+		log.Printf("⚠️  获取模组信息失败，本地副本不包含模组设置: %v\n", err)
+	}
+
+	deletedAt := time.Now()
+	trashed := localTrashMeta{SaveGame: *save, Mods: mods}
+	trashed.DeletedAt = &deletedAt
+
+	meta, err := json.MarshalIndent(&trashed, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	zipPath := filepath.Join(getTrashDir(), save.ID+".zip")
+	if err := os.WriteFile(zipPath, data, 0644); err != nil {
+		return fmt.Errorf("写入存档副本失败: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(getTrashDir(), save.ID+".json"), meta, 0644); err != nil {
+		os.Remove(zipPath)
+		return fmt.Errorf("写入存档信息失败: %w", err)
+	}
+
+	if err := c.api.Load().DeleteSave(ctx, save.ID); err != nil {
+		// 删除失败（如存档已被固定）时不保留本地副本
+		os.Remove(zipPath)
+		os.Remove(filepath.Join(getTrashDir(), save.ID+".json"))
+		return err
+	}
+	return nil
+}
+
+// listTrash 列出服务端和本地回收站中的存档，按删除时间倒序
+func (c *Client) listTrash(ctx context.Context) ([]*TrashItem, error) {
+	var items []*TrashItem
+
+	saves, err := c.api.Load().ListTrash(ctx)
+	if err != nil && !errors.Is(err, ErrTrashUnsupported) {
+		return nil, err
+	}
+	times := loadTrashTimes()
+	for _, save := range saves {
+		if t, ok := times[save.ID]; ok && save.DeletedAt == nil {
+			save.DeletedAt = &t
+		}
+		items = append(items, &TrashItem{Save: save})
+	}
+
+	local, err := listLocalTrash()
+	if err != nil {
+		return nil, fmt.Errorf("读取本地回收站失败: %w", err)
+	}
+	items = append(items, local...)
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].deletedAt().After(items[j].deletedAt())
+	})
+
+	return items, nil
+}
+
+// listLocalTrash 读取本地回收站
+func listLocalTrash() ([]*TrashItem, error) {
+	entries, err := os.ReadDir(getTrashDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*TrashItem
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		metaPath := filepath.Join(getTrashDir(), entry.Name())
+		data, err := os.ReadFile(metaPath)
+		if err != nil {
+			continue
+		}
+
+		var meta localTrashMeta
+		if err := json.Unmarshal(data, &meta); err != nil {
+			log.Printf("本地回收站条目损坏 %s: %v\n", entry.Name(), err)
+			continue
+		}
+
+		items = append(items, &TrashItem{
+			Save:  &meta.SaveGame,
+			Local: true,
+			Path:  strings.TrimSuffix(metaPath, ".json") + ".zip",
+			Mods:  meta.Mods,
+		})
+	}
+
+	return items, nil
+}
+
+// deletedAt 移入回收站的时间，服务端和本地都没有记录时返回零值
+func (item *TrashItem) deletedAt() time.Time {
+	if item.Save.DeletedAt != nil {
+		return *item.Save.DeletedAt
+	}
+	return time.Time{}
+}
+
+// formatDeletedAt 删除时间的显示文本
+func (item *TrashItem) formatDeletedAt(layout string) string {
+	t := item.deletedAt()
+	if t.IsZero() {
+		return "未知时间"
+	}
+	return t.Format(layout)
+}
+
+// 服务端不返回 deleted_at 时，在本地记录从本机移入回收站的时间
+var trashTimesLock sync.Mutex
+
+func getTrashTimesPath() string {
+	return filepath.Join(getAppDataDir(), "trash_times.json")
+}
+
+func loadTrashTimes() map[string]time.Time {
+	times := make(map[string]time.Time)
+	data, err := os.ReadFile(getTrashTimesPath())
+	if err != nil {
+		return times
+	}
+	if err := json.Unmarshal(data, &times); err != nil {
+		log.Printf("读取回收站时间记录失败: %v\n", err)
+	}
+	return times
+}
+
+func saveTrashTimes(times map[string]time.Time) {
+	data, err := json.MarshalIndent(times, "", "  ")
+	if err == nil {
+		err = os.WriteFile(getTrashTimesPath(), data, 0644)
+	}
+	if err != nil {
+		log.Printf("保存回收站时间记录失败: %v\n", err)
+	}
+}
+
+func recordTrashTime(saveID string, t time.Time) {
+	trashTimesLock.Lock()
+	defer trashTimesLock.Unlock()
+
+	times := loadTrashTimes()
+	times[saveID] = t
+	saveTrashTimes(times)
+}
+
+func forgetTrashTime(saveID string) {
+	trashTimesLock.Lock()
+	defer trashTimesLock.Unlock()
+
+	times := loadTrashTimes()
+	if _, ok := times[saveID]; ok {
+		delete(times, saveID)
+		saveTrashTimes(times)
+	}
+}
+
+// restoreFromTrash 从回收站恢复，本地副本会重新上传（生成新的云端记录），
+// 保留原来的游戏、玩家档案、模组设置、备注、标签和固定状态
+func (c *Client) restoreFromTrash(ctx context.Context, item *TrashItem) error {
+	if !item.Local {
+		if err := c.api.Load().UntrashSave(ctx, item.Save.ID); err != nil {
+			return err
+		}
+		forgetTrashTime(item.Save.ID)
+		return nil
+	}
+
+	data, err := os.ReadFile(item.Path)
+	if err != nil {
+		return fmt.Errorf("读取存档副本失败: %w", err)
+	}
+
+	save := item.Save
+	info := &SaveInfo{
+		Game:     save.Game,
+		Folder:   save.CampaignName(),
+		GameMode: save.GameMode,
+		Profile:  save.Profile,
+		SaveName: save.SaveName,
+		Mods:     item.Mods,
+
+		LeaderName: save.CharacterName,
+		GameTime:   save.GameTime,
+	}
+	uploaded, err := c.api.Load().UploadSave(ctx, save.FileName, data, info, nil)
+	if err != nil {
+		return err
+	}
+	if err := removeLocalTrash(item); err != nil {
+		return err
+	}
+
+	if save.Notes == "" && len(save.Tags) == 0 && !save.Pinned {
+		return nil
+	}
+	update := &SaveMetadataUpdate{Notes: &save.Notes, Tags: &save.Tags, Pinned: &save.Pinned}
+	if _, err := c.api.Load().UpdateSaveMetadata(ctx, uploaded.ID, update); err != nil {
+		return fmt.Errorf("存档已恢复，但恢复备注、标签和固定状态失败: %w", err)
+	}
+	return nil
+}
+
+// purgeTrashItem 彻底删除回收站中的存档
+func (c *Client) purgeTrashItem(ctx context.Context, item *TrashItem) error {
+	if item.Local {
+		return removeLocalTrash(item)
+	}
+	if err := c.api.Load().DeleteSave(ctx, item.Save.ID); err != nil {
+		return err
+	}
+	forgetTrashTime(item.Save.ID)
+	return nil
+}
+
+func removeLocalTrash(item *TrashItem) error {
+	if err := os.Remove(item.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Remove(strings.TrimSuffix(item.Path, ".zip") + ".json")
+}
+
+// purgeExpiredTrash 彻底删除超过保留天数的回收站存档
+func (c *Client) purgeExpiredTrash() {
+	days := c.config.Load().TrashRetentionDays
+	if days <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	items, err := c.listTrash(ctx)
+	if err != nil {
+		log.Printf("读取回收站失败: %v\n", err)
+		return
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -days)
+	for _, item := range items {
+		// 不知道删除时间的存档不自动清除，避免刚删除的旧存档被立即清除
+		if item.deletedAt().IsZero() || item.deletedAt().After(cutoff) {
+			continue
+		}
+
+		if err := c.purgeTrashItem(ctx, item); err != nil {
+			log.Printf("清除回收站存档失败 %s: %v\n", item.Save.FileName, err)
+			continue
+		}
+		log.Printf("已自动清除回收站存档: %s (删除于 %s)\n",
+			item.Save.FileName, item.formatDeletedAt("2006-01-02 15:04:05"))
+	}
+}
+
+// monitorTrash 定期清除过期的回收站存档
+func (c *Client) monitorTrash() {
+	c.purgeExpiredTrash()
+
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		c.purgeExpiredTrash()
+	}
+}
+
+// showTrash 回收站窗口
+func (c *Client) showTrash() {
+	win := c.app.NewWindow("回收站")
+	win.Resize(fyne.NewSize(700, 450))
+
+	var items []*TrashItem
+	summary := widget.NewLabel("正在加载回收站...")
+
+	var reload func()
+
+	// 在后台执行回收站操作，完成后重新加载
+	run := func(status string, op func(ctx context.Context) error) {
+		summary.SetText(status)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			defer cancel()
+
+			err := op(ctx)
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, win)
+				}
+				reload()
+				c.refreshSavesList()
+			})
+		}()
+	}
+
+	list := widget.NewList(
+		func() int { return len(items) },
+		func() fyne.CanvasObject {
+			return container.NewBorder(
+				nil, nil, nil,
+				container.NewHBox(
+					widget.NewButton("恢复", nil),
+					widget.NewButton("彻底删除", nil),
+				),
+				widget.NewLabel(""),
+			)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(items) {
+				return
+			}
+
+			item := items[id]
+			row := obj.(*fyne.Container)
+
+			text := fmt.Sprintf("删除于 %s - %s (%s, %s)",
+				item.formatDeletedAt("2006-01-02 15:04"),
+				item.Save.CampaignName(),
+				item.Save.Timestamp.Format("2006-01-02 15:04:05"),
+				formatSize(item.Save.FileSize),
+			)
+			if item.Local {
+				text += " [本地副本]"
+			}
+			row.Objects[0].(*widget.Label).SetText(text)
+
+			buttons := row.Objects[1].(*fyne.Container)
+			buttons.Objects[0].(*widget.Button).OnTapped = func() {
+				run("正在恢复...", func(ctx context.Context) error {
+					return c.restoreFromTrash(ctx, item)
+				})
+			}
+			buttons.Objects[1].(*widget.Button).OnTapped = func() {
+				dialog.ShowConfirm("确认删除", "确定要彻底删除这个存档?\n\n此操作不可恢复!", func(ok bool) {
+					if !ok {
+						return
+					}
+					run("正在删除...", func(ctx context.Context) error {
+						return c.purgeTrashItem(ctx, item)
+					})
+				}, win)
+			}
+		},
+	)
+
+	reload = func() {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			result, err := c.listTrash(ctx)
+			fyne.Do(func() {
+				if err != nil {
+					summary.SetText(fmt.Sprintf("加载失败: %v", err))
+					return
+				}
+				items = result
+				list.Refresh()
+				summary.SetText(fmt.Sprintf("回收站中有 %d 个存档，超过 %d 天自动清除",
+					len(items), c.config.Load().TrashRetentionDays))
+			})
+		}()
+	}
+
+	refreshBtn := widget.NewButton("刷新", reload)
+
+	win.SetContent(container.NewPadded(container.NewBorder(
+		container.NewBorder(nil, nil, nil, refreshBtn, summary),
+		nil, nil, nil,
+		list,
+	)))
+	win.Show()
+
+	reload()
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"time"
 )
 
@@ -13,14 +14,54 @@ type SaveGame struct {
 	FileName    string    `json:"file_name"`
 	FileSize    int64     `json:"file_size"`
 	DeviceID    string    `json:"device_id"`
-	GameTime    int       `json:"game_time,omitempty"`
+	GameTime    int       `json:"game_time,omitempty"` // 游戏时长（秒）
 	Notes       string    `json:"notes,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+
+	// 存档元数据（上传时由客户端解析）
+	Campaign      string `json:"campaign,omitempty"`
+	GameMode      string `json:"game_mode,omitempty"`
+	SaveName      string `json:"save_name,omitempty"`
+	CharacterName string `json:"character_name,omitempty"` // 存档中的队伍领袖
+	Profile       string `json:"profile,omitempty"`        // 上传时所属的玩家档案，恢复到同一档案
+	Game          string `json:"game,omitempty"`           // 所属游戏，旧版本上传的存档为空（博德之门3）
+	Pinned        bool   `json:"pinned,omitempty"`
+
+	DeletedAt *time.Time `json:"deleted_at,omitempty"` // 移入回收站的时间
+}
+
+// CampaignName 存档所属战役（文件夹名）
+func (s *SaveGame) CampaignName() string {
+	if s.Campaign != "" {
+		return s.Campaign
+	}
+	return strings.TrimSuffix(s.FileName, ".zip")
+}
+
+// SaveMetadataUpdate 存档元数据更新，nil 字段保持不变
+type SaveMetadataUpdate struct {
+	Notes  *string   `json:"notes,omitempty"`
+	Tags   *[]string `json:"tags,omitempty"`
+	Pinned *bool     `json:"pinned,omitempty"`
 }
 
 // SaveGameListResponse 存档列表响应
 type SaveGameListResponse struct {
-	Saves []*SaveGame `json:"saves"`
-	Total int         `json:"total"`
+	Saves      []*SaveGame `json:"saves"`
+	Total      int         `json:"total"`
+	NextCursor string      `json:"next_cursor,omitempty"` // 为空表示没有更多
+}
+
+// ListSavesOptions 存档列表查询参数（分页与过滤）
+type ListSavesOptions struct {
+	Limit    int
+	Cursor   string    // 上一页返回的 next_cursor
+	Folder   string    // 战役文件夹名，如 <uuid>__HonourMode
+	DeviceID string    // 上传设备
+	Since    time.Time // 起始时间（含）
+	Until    time.Time // 结束时间（不含）
+	Tag      string
+	GameMode string // 如 HonourMode
 }
 
 // ErrorResponse 错误响应
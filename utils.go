@@ -3,13 +3,13 @@ package main
 import (
 	"archive/zip"
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 )
@@ -36,13 +36,30 @@ func (d *Debouncer) Do(fn func()) {
 	d.timer = time.AfterFunc(d.delay, fn)
 }
 
+// Stop 取消还没有执行的调用
+func (d *Debouncer) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+}
+
+// 数据目录，通过 -data-dir 或 BG3SYNC_DATA_DIR 指定，为空使用默认位置
+var dataDir string
+
 // 获取应用数据目录
 func getAppDataDir() string {
 	var dir string
-	if runtime.GOOS == "windows" {
+	switch {
+	case dataDir != "":
+		dir = dataDir
+	case runtime.GOOS == "windows":
 		appData := os.Getenv("APPDATA")
 		dir = filepath.Join(appData, "BG3SyncClient")
-	} else {
+	default:
 		// macOS/Linux 开发环境
 		home, _ := os.UserHomeDir()
 		dir = filepath.Join(home, ".bg3sync")
@@ -51,40 +68,6 @@ func getAppDataDir() string {
 	return dir
 }
 
-// 配置管理
-func getConfigPath() string {
-	return filepath.Join(getAppDataDir(), "config.json")
-}
-
-func loadConfig() *Config {
-	path := getConfigPath()
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return &Config{
-			AutoSync:   true,
-			AutoUpload: true,
-		}
-	}
-
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		return &Config{
-			AutoSync:   true,
-			AutoUpload: true,
-		}
-	}
-	return &config
-}
-
-func saveConfig(config *Config) error {
-	path := getConfigPath()
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(path, data, 0644)
-}
-
 // 格式化文件大小
 func formatSize(bytes int64) string {
 	const unit = 1024
@@ -99,6 +82,15 @@ func formatSize(bytes int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
+// 格式化游戏时长（秒）
+func formatPlaytime(seconds int) string {
+	if seconds <= 0 {
+		return "未知"
+	}
+	d := time.Duration(seconds) * time.Second
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
 // 生成设备ID
 func generateDeviceID() string {
 	hostname, _ := os.Hostname()
@@ -230,8 +222,18 @@ func unzipToFolder(zipData []byte, destPath string) error {
 
 	// 解压每个文件
 	for _, file := range reader.File {
+		// 同步元数据（如模组设置）不属于存档
+		if isSyncMeta(file.Name) {
+			continue
+		}
+
 		filePath := filepath.Join(destPath, file.Name)
 
+		// 拒绝指向目标文件夹之外的路径
+		if !strings.HasPrefix(filePath, filepath.Clean(destPath)+string(os.PathSeparator)) {
+			return fmt.Errorf("压缩包包含非法路径: %s", file.Name)
+		}
+
 		// 创建子文件夹（如果需要）
 		if file.FileInfo().IsDir() {
 			os.MkdirAll(filePath, 0755)